@@ -2,10 +2,12 @@ package controllers
 
 import (
 	"errors"
+	"log"
 	"net/http"
 	"strings"
 	"time"
 
+	"github.com/abdullahalsazib/e-com-backend/helper"
 	"github.com/abdullahalsazib/e-com-backend/models"
 	"github.com/abdullahalsazib/e-com-backend/utils"
 	"github.com/gin-gonic/gin"
@@ -87,6 +89,11 @@ func (ac *AuthController) Register(c *gin.Context) {
 		RoleID: role.ID,
 	})
 
+	// keep what the visitor put in the cart before registering
+	if err := helper.MergeGuestCart(ac.DB, c.GetHeader("X-Cart-Token"), user.ID); err != nil {
+		log.Printf("Failed to merge guest cart of user %d: %v", user.ID, err)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User registered successfully",
 		"user":    user,
@@ -147,6 +154,14 @@ func (ac *AuthController) Login(c *gin.Context) {
 		return
 	}
 
+	// carry the anonymous browsing history and guest cart over to the account
+	if err := helper.MergeViewHistory(ac.DB, clientID(c), user.ID); err != nil {
+		log.Printf("Failed to merge browsing history of user %d: %v", user.ID, err)
+	}
+	if err := helper.MergeGuestCart(ac.DB, c.GetHeader("X-Cart-Token"), user.ID); err != nil {
+		log.Printf("Failed to merge guest cart of user %d: %v", user.ID, err)
+	}
+
 	// Set refresh token as HttpOnly cookie
 	c.SetCookie(
 		"refresh_token",
@@ -1,8 +1,11 @@
 package controllers
 
 import (
+	"io"
+	"log"
 	"net/http"
 
+	"github.com/abdullahalsazib/e-com-backend/helper"
 	"github.com/abdullahalsazib/e-com-backend/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -16,38 +19,109 @@ func NewCartController(DB *gorm.DB) CartController {
 	return CartController{DB}
 }
 
-// GetCart retrieves the user's cart with items
-func (cc *CartController) GetCart(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
+// The cart handlers serve both /auth/cart (the logged-in user's cart) and
+// /guest/cart (an anonymous cart identified by the X-Cart-Token header).
 
+// currentCart loads the cart of the request. With create set, a missing cart
+// is created; a new guest cart's token is sent back in the X-Cart-Token header.
+// It writes the error response itself when it returns false.
+func (cc *CartController) currentCart(c *gin.Context, create bool) (*models.Cart, bool) {
 	var cart models.Cart
-	result := cc.DB.Preload("CartItems.Product").Where("user_id = ?", userID).First(&cart)
-
-	if result.Error != nil {
-		if result.Error == gorm.ErrRecordNotFound {
-			// Create a new cart if none exists for this user
-			newCart := models.Cart{UserID: userID}
-			if err := cc.DB.Create(&newCart).Error; err != nil {
-				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
-				return
-			}
-			// Reload the new cart with preloaded CartItems.Product (should be empty)
-			cc.DB.Preload("CartItems.Product").First(&newCart, newCart.ID)
-			c.JSON(http.StatusOK, gin.H{"data": newCart})
-			return
+
+	if value, ok := c.Get("user_id"); ok {
+		userID := value.(uint)
+		query := cc.DB.Where("user_id = ?", userID)
+		var err error
+		if create {
+			err = query.FirstOrCreate(&cart, models.Cart{UserID: &userID}).Error
+		} else {
+			err = query.First(&cart).Error
+		}
+		if err != nil {
+			cartError(c, err)
+			return nil, false
+		}
+		return &cart, true
+	}
+
+	if guestID, ok := helper.ParseCartToken(c.GetHeader("X-Cart-Token")); ok {
+		err := cc.DB.Where("guest_token = ?", guestID).First(&cart).Error
+		if err == nil {
+			return &cart, true
 		}
+		if err != gorm.ErrRecordNotFound || !create {
+			cartError(c, err)
+			return nil, false
+		}
+	} else if !create {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+		return nil, false
+	}
+
+	guestID, token, err := helper.NewCartToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
+		return nil, false
+	}
+	cart = models.Cart{GuestToken: &guestID}
+	if err := cc.DB.Create(&cart).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create cart"})
+		return nil, false
+	}
+	c.Header("X-Cart-Token", token)
+	return &cart, true
+}
 
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+func cartError(c *gin.Context, err error) {
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
 		return
 	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch cart"})
+}
+
+// respondCart returns the cart with its items, checked against the live
+// products; what changed since the items were added is listed in warnings.
+// Any cart request counts as activity for the abandoned cart reminders.
+func (cc *CartController) respondCart(c *gin.Context, cartID uint) {
+	if err := helper.TouchCart(cc.DB, cartID); err != nil {
+		log.Printf("Failed to record activity on cart %d: %v", cartID, err)
+	}
 
-	c.JSON(http.StatusOK, gin.H{"data": cart})
+	var cart models.Cart
+	helper.PreloadCartItems(cc.DB).First(&cart, cartID)
+	warnings, err := helper.ValidateCart(cc.DB, &cart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate cart"})
+		return
+	}
+	response := gin.H{"data": cart, "warnings": warnings}
+	if token := c.Writer.Header().Get("X-Cart-Token"); token != "" {
+		response["cart_token"] = token
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// GetCart retrieves the user's cart with items
+func (cc *CartController) GetCart(c *gin.Context) {
+	// a guest without a cart just sees an empty one
+	_, isUser := c.Get("user_id")
+	if _, ok := helper.ParseCartToken(c.GetHeader("X-Cart-Token")); !isUser && !ok {
+		c.JSON(http.StatusOK, gin.H{"data": models.Cart{CartItems: []models.CartItem{}, SavedItems: []models.CartItem{}}, "warnings": []models.CartWarning{}})
+		return
+	}
+
+	// Create a new cart if none exists for this user
+	cart, ok := cc.currentCart(c, true)
+	if !ok {
+		return
+	}
+
+	cc.respondCart(c, cart.ID)
 }
 
 // AddToCart adds an item to the cart
 func (cc *CartController) AddToCart(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
 	var request models.AddToCartRequest
 	if err := c.ShouldBindJSON(&request); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -61,45 +135,23 @@ func (cc *CartController) AddToCart(c *gin.Context) {
 		return
 	}
 
-	// Get or create user's cart
-	var cart models.Cart
-	if err := cc.DB.Where("user_id = ?", userID).FirstOrCreate(&cart, models.Cart{UserID: userID}).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get/create cart"})
+	// Get or create the cart
+	cart, ok := cc.currentCart(c, true)
+	if !ok {
 		return
 	}
 
-	// Check if item already exists in cart
-	var existingItem models.CartItem
-	result := cc.DB.Where("cart_id = ? AND id = ?", cart.ID, request.ProductID).First(&existingItem)
-
-	if result.Error == nil {
-		// Update quantity if item exists
-		existingItem.Quantity += request.Quantity
-		if err := cc.DB.Save(&existingItem).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
-			return
-		}
-	} else {
-		// Create new cart item
-		newItem := models.CartItem{
-			CartID:    cart.ID,
-			ProductID: request.ProductID,
-			Quantity:  request.Quantity,
-		}
-		if err := cc.DB.Create(&newItem).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
-			return
-		}
+	if err := addCartItem(cc.DB, cart.ID, &product, request.Quantity); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add item to cart"})
+		return
 	}
 
 	// Return updated cart
-	cc.DB.Preload("CartItems.Product").First(&cart, cart.ID)
-	c.JSON(http.StatusOK, gin.H{"data": cart})
+	cc.respondCart(c, cart.ID)
 }
 
 // UpdateCartItem updates a cart item's quantity
 func (cc *CartController) UpdateCartItem(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
 	itemID := c.Param("itemId")
 
 	var request models.UpdateCartItemRequest
@@ -108,11 +160,14 @@ func (cc *CartController) UpdateCartItem(c *gin.Context) {
 		return
 	}
 
-	// Verify cart item exists and belongs to user
+	cart, ok := cc.currentCart(c, false)
+	if !ok {
+		return
+	}
+
+	// Verify cart item exists and belongs to the cart
 	var cartItem models.CartItem
-	if err := cc.DB.Joins("JOIN carts ON carts.id = cart_items.cart_id").
-		Where("cart_items.id = ? AND carts.user_id = ?", itemID, userID).
-		First(&cartItem).Error; err != nil {
+	if err := cc.DB.Where("id = ? AND cart_id = ?", itemID, cart.ID).First(&cartItem).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 		return
 	}
@@ -125,21 +180,21 @@ func (cc *CartController) UpdateCartItem(c *gin.Context) {
 	}
 
 	// Return updated cart
-	var cart models.Cart
-	cc.DB.Preload("CartItems.Product").First(&cart, cartItem.CartID)
-	c.JSON(http.StatusOK, gin.H{"data": cart})
+	cc.respondCart(c, cart.ID)
 }
 
 // RemoveFromCart removes an item from the cart
 func (cc *CartController) RemoveFromCart(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
 	itemID := c.Param("itemId")
 
-	// Verify cart item exists and belongs to user
+	cart, ok := cc.currentCart(c, false)
+	if !ok {
+		return
+	}
+
+	// Verify cart item exists and belongs to the cart
 	var cartItem models.CartItem
-	if err := cc.DB.Joins("JOIN carts ON carts.id = cart_items.cart_id").
-		Where("cart_items.id = ? AND carts.user_id = ?", itemID, userID).
-		First(&cartItem).Error; err != nil {
+	if err := cc.DB.Where("id = ? AND cart_id = ?", itemID, cart.ID).First(&cartItem).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
 		return
 	}
@@ -150,27 +205,169 @@ func (cc *CartController) RemoveFromCart(c *gin.Context) {
 	}
 
 	// Return updated cart
-	var cart models.Cart
-	cc.DB.Preload("CartItems.Product").First(&cart, cartItem.CartID)
-	c.JSON(http.StatusOK, gin.H{"data": cart})
+	cc.respondCart(c, cart.ID)
 }
 
-// ClearCart removes all items from the cart
+// ClearCart removes all items from the cart, keeping the ones saved for later
 func (cc *CartController) ClearCart(c *gin.Context) {
-	userID := c.MustGet("user_id").(uint)
-
-	// Get user's cart
-	var cart models.Cart
-	if err := cc.DB.Where("user_id = ?", userID).First(&cart).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Cart not found"})
+	cart, ok := cc.currentCart(c, false)
+	if !ok {
 		return
 	}
 
 	// Delete all cart items
-	if err := cc.DB.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+	if err := cc.DB.Where("cart_id = ? AND saved_for_later = ?", cart.ID, false).Delete(&models.CartItem{}).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Cart cleared successfully"})
 }
+
+// SaveForLater moves a cart item to the saved for later section
+func (cc *CartController) SaveForLater(c *gin.Context) {
+	cc.setSavedForLater(c, true)
+}
+
+// MoveToCart moves an item saved for later back into the cart
+func (cc *CartController) MoveToCart(c *gin.Context) {
+	cc.setSavedForLater(c, false)
+}
+
+func (cc *CartController) setSavedForLater(c *gin.Context, saved bool) {
+	itemID := c.Param("itemId")
+
+	cart, ok := cc.currentCart(c, false)
+	if !ok {
+		return
+	}
+
+	var cartItem models.CartItem
+	if err := cc.DB.Where("id = ? AND cart_id = ?", itemID, cart.ID).First(&cartItem).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+		return
+	}
+
+	if err := cc.DB.Model(&cartItem).Update("saved_for_later", saved).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update cart item"})
+		return
+	}
+
+	cc.respondCart(c, cart.ID)
+}
+
+// MoveToWishlist moves a cart item to the user's wishlist. A product that is
+// already in the wishlist is only taken out of the cart.
+func (cc *CartController) MoveToWishlist(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	itemID := c.Param("itemId")
+
+	cart, ok := cc.currentCart(c, false)
+	if !ok {
+		return
+	}
+
+	var cartItem models.CartItem
+	if err := cc.DB.Preload("Product").Where("id = ? AND cart_id = ?", itemID, cart.ID).First(&cartItem).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cart item not found"})
+		return
+	}
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&cartItem).Error; err != nil {
+			return err
+		}
+		var count int64
+		if err := tx.Model(&models.WishlistItem{}).
+			Where("user_id = ? AND product_id = ?", userID, cartItem.ProductID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return nil
+		}
+		return tx.Create(&models.WishlistItem{
+			UserID:     userID,
+			ProductID:  cartItem.ProductID,
+			PriceAtAdd: cartItem.Product.Price,
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move item to wishlist"})
+		return
+	}
+
+	cc.respondCart(c, cart.ID)
+}
+
+// MoveWishlistToCart moves a wishlist item into the cart with the given
+// quantity, dropping its back-in-stock request
+func (cc *CartController) MoveWishlistToCart(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	itemID := c.Param("id")
+
+	var request models.MoveToCartRequest
+	if err := c.ShouldBindJSON(&request); err != nil && err != io.EOF {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if request.Quantity == 0 {
+		request.Quantity = 1
+	}
+
+	var item models.WishlistItem
+	if err := cc.DB.Where("id = ? AND user_id = ?", itemID, userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist item not found"})
+		return
+	}
+
+	var product models.Product
+	if err := cc.DB.First(&product, item.ProductID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+
+	cart, ok := cc.currentCart(c, true)
+	if !ok {
+		return
+	}
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&item).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ? AND product_id = ? AND source = ?", userID, item.ProductID, models.SubscriptionSourceWishlist).
+			Delete(&models.StockSubscription{}).Error; err != nil {
+			return err
+		}
+		return addCartItem(tx, cart.ID, &product, request.Quantity)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to move item to cart"})
+		return
+	}
+
+	cc.respondCart(c, cart.ID)
+}
+
+// addCartItem puts quantity of product into the cart. A product already in
+// the cart, or saved for later, gets the quantity added and is back in the cart.
+func addCartItem(db *gorm.DB, cartID uint, product *models.Product, quantity int) error {
+	var existingItem models.CartItem
+	err := db.Where("cart_id = ? AND product_id = ?", cartID, product.ID).First(&existingItem).Error
+	if err == nil {
+		return db.Model(&existingItem).Updates(map[string]interface{}{
+			"quantity":        existingItem.Quantity + quantity,
+			"saved_for_later": false,
+		}).Error
+	}
+	if err != gorm.ErrRecordNotFound {
+		return err
+	}
+
+	return db.Create(&models.CartItem{
+		CartID:     cartID,
+		ProductID:  product.ID,
+		Quantity:   quantity,
+		PriceAtAdd: product.Price,
+	}).Error
+}
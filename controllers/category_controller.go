@@ -2,7 +2,9 @@ package controllers
 
 import (
 	"net/http"
+	"strconv"
 
+	"github.com/abdullahalsazib/e-com-backend/helper"
 	"github.com/abdullahalsazib/e-com-backend/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -28,3 +30,52 @@ func (cc *CategoryController) GetCategories(c *gin.Context) {
 
 	c.JSON(http.StatusOK, gin.H{"categories": categories})
 }
+
+// GET /categories/tree?depth=2
+func (cc *CategoryController) GetCategoryTree(c *gin.Context) {
+	roots, _, err := helper.CategoryTree(cc.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get categories"})
+		return
+	}
+
+	depth, _ := strconv.Atoi(c.DefaultQuery("depth", "10"))
+	if depth < 1 || depth > 10 {
+		depth = 10
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": helper.TrimCategoryTree(roots, depth)})
+}
+
+// GET /categories/:id - by ID or slug, with breadcrumbs and direct children.
+// Digits are always read as an ID; category slugs are never purely numeric.
+func (cc *CategoryController) GetCategory(c *gin.Context) {
+	_, nodes, err := helper.CategoryTree(cc.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to get category"})
+		return
+	}
+
+	idOrSlug := c.Param("id")
+	var node *models.CategoryNode
+	if id, err := strconv.Atoi(idOrSlug); err == nil {
+		node = nodes[uint(id)]
+	} else {
+		for _, n := range nodes {
+			if n.Slug == idOrSlug {
+				node = n
+				break
+			}
+		}
+	}
+	if node == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	category := helper.TrimCategoryTree([]*models.CategoryNode{node}, 2)[0]
+	c.JSON(http.StatusOK, gin.H{
+		"data":        category,
+		"breadcrumbs": helper.CategoryBreadcrumbs(nodes, node),
+	})
+}
@@ -0,0 +1,346 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type CollectionController struct {
+	DB *gorm.DB
+}
+
+func NewCollectionController(DB *gorm.DB) CollectionController {
+	return CollectionController{DB}
+}
+
+var errSlugTaken = errors.New("slug already taken")
+
+// pagination reads page and limit from the query string
+func pagination(c *gin.Context) (int, int) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+	return page, limit
+}
+
+// GetCollections lists the collections that are active right now
+func (cc *CollectionController) GetCollections(c *gin.Context) {
+	page, limit := pagination(c)
+
+	query := cc.DB.Model(&models.Collection{}).Scopes(helper.ActiveCollections)
+	var total int64
+	query.Count(&total)
+
+	var collections []models.Collection
+	if err := query.Order("sort_order, id").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": collections, "total": total, "page": page, "limit": limit})
+}
+
+// GetCollection returns an active collection by slug with a page of its products
+func (cc *CollectionController) GetCollection(c *gin.Context) {
+	var collection models.Collection
+	if err := cc.DB.Scopes(helper.ActiveCollections).
+		Where("slug = ?", c.Param("slug")).
+		First(&collection).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	page, limit := pagination(c)
+	query := helper.CollectionProducts(cc.DB, &collection).Scopes(helper.CustomerVisible)
+	if c.Query("include_out_of_stock") != "true" {
+		query = query.Scopes(helper.InStock)
+	}
+
+	var total int64
+	query.Session(&gorm.Session{}).Count(&total)
+
+	var products []models.Product
+	if err := query.
+		Preload("Category").
+		Preload("Tags").
+		Preload("Vendor").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collection products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"collection": collection,
+		"data":       products,
+		"total":      total,
+		"page":       page,
+		"limit":      limit,
+	})
+}
+
+// GetTags lists the tags in use with their product counts
+func (cc *CollectionController) GetTags(c *gin.Context) {
+	type tagRow struct {
+		ID       uint   `json:"id"`
+		Name     string `json:"name"`
+		Slug     string `json:"slug"`
+		Products int    `json:"products"`
+	}
+
+	var tags []tagRow
+	if err := cc.DB.Table("tags").
+		Select("tags.id, tags.name, tags.slug, COUNT(product_tags.product_id) AS products").
+		Joins("JOIN product_tags ON product_tags.tag_id = tags.id").
+		Group("tags.id, tags.name, tags.slug").
+		Order("products DESC, tags.name").
+		Scan(&tags).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch tags"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tags})
+}
+
+// ListCollections lists every collection for the superadmin, including
+// inactive and scheduled ones
+func (cc *CollectionController) ListCollections(c *gin.Context) {
+	page, limit := pagination(c)
+
+	var total int64
+	cc.DB.Model(&models.Collection{}).Count(&total)
+
+	var collections []models.Collection
+	if err := cc.DB.Preload("RuleTag").
+		Order("sort_order, id").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&collections).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch collections"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": collections, "total": total, "page": page, "limit": limit})
+}
+
+// CreateCollection creates a manual or rule based collection
+func (cc *CollectionController) CreateCollection(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	collection := models.Collection{CreatedBy: userID}
+	if !cc.saveCollection(c, &collection, &request) {
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": collection})
+}
+
+// UpdateCollection replaces the settings of a collection
+func (cc *CollectionController) UpdateCollection(c *gin.Context) {
+	var collection models.Collection
+	if err := cc.DB.First(&collection, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	var request models.CollectionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if !cc.saveCollection(c, &collection, &request) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": collection})
+}
+
+// DeleteCollection removes a collection and its curated items
+func (cc *CollectionController) DeleteCollection(c *gin.Context) {
+	var collection models.Collection
+	if err := cc.DB.First(&collection, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&collection).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete collection"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection deleted successfully"})
+}
+
+// SetCollectionProducts replaces the ordered product list of a manual collection
+func (cc *CollectionController) SetCollectionProducts(c *gin.Context) {
+	var collection models.Collection
+	if err := cc.DB.First(&collection, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Collection not found"})
+		return
+	}
+	if collection.Type != models.CollectionManual {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only manual collections have a product list"})
+		return
+	}
+
+	var request models.SetCollectionProductsRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	items := make([]models.CollectionItem, 0, len(request.ProductIDs))
+	seen := map[uint]bool{}
+	for _, productID := range request.ProductIDs {
+		if seen[productID] {
+			continue
+		}
+		seen[productID] = true
+		items = append(items, models.CollectionItem{
+			CollectionID: collection.ID,
+			ProductID:    productID,
+			Position:     len(items) + 1,
+		})
+	}
+
+	var found int64
+	cc.DB.Model(&models.Product{}).Where("id IN ?", request.ProductIDs).Count(&found)
+	if int(found) != len(items) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Some products do not exist"})
+		return
+	}
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionItem{}).Error; err != nil {
+			return err
+		}
+		if len(items) == 0 {
+			return nil
+		}
+		return tx.Create(&items).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update collection products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Collection products updated", "products": len(items)})
+}
+
+// saveCollection validates the request, copies it onto the collection and
+// saves it. It writes the error response itself and reports whether it succeeded.
+func (cc *CollectionController) saveCollection(c *gin.Context, collection *models.Collection, request *models.CollectionRequest) bool {
+	if request.StartsAt != nil && request.EndsAt != nil && !request.EndsAt.After(*request.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+		return false
+	}
+	if request.RuleMinPrice != nil && request.RuleMaxPrice != nil && *request.RuleMinPrice > *request.RuleMaxPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rule_min_price cannot exceed rule_max_price"})
+		return false
+	}
+	if request.Type == models.CollectionRule && request.RuleTag == "" && request.RuleCategoryID == nil &&
+		request.RuleVendorID == nil && request.RuleMinPrice == nil && request.RuleMaxPrice == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A rule collection needs at least one rule"})
+		return false
+	}
+	if request.Type == models.CollectionRule && request.RuleTag != "" && helper.Slugify(request.RuleTag) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rule_tag"})
+		return false
+	}
+
+	slug := helper.Slugify(request.Slug)
+	if slug == "" {
+		slug = helper.Slugify(request.Name)
+	}
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid collection slug"})
+		return false
+	}
+
+	collection.Name = request.Name
+	collection.Slug = slug
+	collection.Description = request.Description
+	collection.ImageURL = request.ImageURL
+	collection.Type = request.Type
+	collection.IsActive = request.IsActive
+	collection.SortOrder = request.SortOrder
+	collection.StartsAt = request.StartsAt
+	collection.EndsAt = request.EndsAt
+	collection.RuleTagID = nil
+	collection.RuleTag = nil
+	collection.RuleCategoryID = nil
+	collection.RuleVendorID = nil
+	collection.RuleMinPrice = nil
+	collection.RuleMaxPrice = nil
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Collection{}).
+			Where("slug = ? AND id <> ?", slug, collection.ID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errSlugTaken
+		}
+
+		if request.Type == models.CollectionRule {
+			if request.RuleTag != "" {
+				tags, err := helper.ResolveTags(tx, []string{request.RuleTag})
+				if err != nil {
+					return err
+				}
+				if len(tags) > 0 {
+					collection.RuleTagID = &tags[0].ID
+				}
+			}
+			collection.RuleCategoryID = request.RuleCategoryID
+			collection.RuleVendorID = request.RuleVendorID
+			collection.RuleMinPrice = request.RuleMinPrice
+			collection.RuleMaxPrice = request.RuleMaxPrice
+		}
+
+		if err := tx.Save(collection).Error; err != nil {
+			return err
+		}
+		if request.Type == models.CollectionRule {
+			// rule collections have no curated items
+			return tx.Where("collection_id = ?", collection.ID).Delete(&models.CollectionItem{}).Error
+		}
+		return nil
+	})
+	if errors.Is(err, errSlugTaken) {
+		c.JSON(http.StatusConflict, gin.H{"error": "A collection with this slug already exists"})
+		return false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save collection"})
+		return false
+	}
+	return true
+}
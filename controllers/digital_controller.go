@@ -0,0 +1,253 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DigitalController struct {
+	DB *gorm.DB
+}
+
+func NewDigitalController(DB *gorm.DB) DigitalController {
+	return DigitalController{DB}
+}
+
+// digitalProduct loads a vendor's product and makes sure it is digital
+func digitalProduct(db *gorm.DB, c *gin.Context) (*models.Product, bool) {
+	product, ok := vendorProduct(db, c, c.Param("id"))
+	if !ok {
+		return nil, false
+	}
+	if product.Type != models.ProductTypeDigital {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only digital products have files and license keys"})
+		return nil, false
+	}
+	return product, true
+}
+
+// UploadFile stores a downloadable file of a digital product in private storage
+func (dc *DigitalController) UploadFile(c *gin.Context) {
+	product, ok := digitalProduct(dc.DB, c)
+	if !ok {
+		return
+	}
+
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "file is required"})
+		return
+	}
+
+	dir := filepath.Join(helper.PrivateStorageDir(), strconv.Itoa(int(product.ID)))
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	storagePath := filepath.Join(dir, uuid.NewString())
+	if err := c.SaveUploadedFile(header, storagePath); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+
+	file := models.ProductFile{
+		ProductID:   product.ID,
+		FileName:    filepath.Base(header.Filename),
+		StoragePath: storagePath,
+		Size:        header.Size,
+		ContentType: header.Header.Get("Content-Type"),
+	}
+	if err := dc.DB.Create(&file).Error; err != nil {
+		os.Remove(storagePath)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save file"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": file})
+}
+
+// GetFiles lists the files of a digital product
+func (dc *DigitalController) GetFiles(c *gin.Context) {
+	product, ok := digitalProduct(dc.DB, c)
+	if !ok {
+		return
+	}
+
+	var files []models.ProductFile
+	if err := dc.DB.Where("product_id = ?", product.ID).Order("id").Find(&files).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch files"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": files})
+}
+
+// DeleteFile removes a file of a digital product
+func (dc *DigitalController) DeleteFile(c *gin.Context) {
+	product, ok := digitalProduct(dc.DB, c)
+	if !ok {
+		return
+	}
+
+	var file models.ProductFile
+	if err := dc.DB.Where("id = ? AND product_id = ?", c.Param("fileId"), product.ID).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+	if err := dc.DB.Delete(&file).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete file"})
+		return
+	}
+	os.Remove(file.StoragePath)
+
+	c.JSON(http.StatusOK, gin.H{"message": "File deleted successfully"})
+}
+
+// AddLicenseKeys adds keys to a digital product's pool, skipping duplicates
+func (dc *DigitalController) AddLicenseKeys(c *gin.Context) {
+	product, ok := digitalProduct(dc.DB, c)
+	if !ok {
+		return
+	}
+
+	var request models.AddLicenseKeysRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	keys := make([]models.LicenseKey, 0, len(request.Keys))
+	for _, key := range request.Keys {
+		keys = append(keys, models.LicenseKey{ProductID: product.ID, Key: key})
+	}
+	result := dc.DB.Clauses(clause.OnConflict{DoNothing: true}).Create(&keys)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add license keys"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "License keys added",
+		"added":      result.RowsAffected,
+		"duplicates": int64(len(keys)) - result.RowsAffected,
+	})
+}
+
+// GetLicenseKeys reports the key pool of a digital product. Unassigned keys
+// are not listed, only counted.
+func (dc *DigitalController) GetLicenseKeys(c *gin.Context) {
+	product, ok := digitalProduct(dc.DB, c)
+	if !ok {
+		return
+	}
+
+	var available int64
+	dc.DB.Model(&models.LicenseKey{}).Where("product_id = ? AND order_item_id IS NULL", product.ID).Count(&available)
+
+	var assigned []models.LicenseKey
+	if err := dc.DB.Where("product_id = ? AND order_item_id IS NOT NULL", product.ID).
+		Order("assigned_at DESC").Find(&assigned).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch license keys"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"available": available, "assigned": assigned})
+}
+
+// GetOrderDownloads lists the digital items of a paid order with fresh signed
+// download links and their license keys
+func (dc *DigitalController) GetOrderDownloads(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var grants []models.DownloadGrant
+	if err := dc.DB.Preload("Product").
+		Where("order_id = ? AND user_id = ? AND revoked_at IS NULL", c.Param("orderId"), userID).
+		Order("id").Find(&grants).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch downloads"})
+		return
+	}
+
+	type fileLink struct {
+		ID        uint   `json:"id"`
+		FileName  string `json:"file_name"`
+		Size      int64  `json:"size"`
+		URL       string `json:"url"`
+		ExpiresAt int64  `json:"expires_at"`
+	}
+	type download struct {
+		models.DownloadGrant
+		Files       []fileLink `json:"files"`
+		LicenseKeys []string   `json:"license_keys"`
+	}
+
+	data := make([]download, 0, len(grants))
+	for _, grant := range grants {
+		var files []models.ProductFile
+		dc.DB.Where("product_id = ?", grant.ProductID).Order("id").Find(&files)
+		links := make([]fileLink, 0, len(files))
+		for _, file := range files {
+			url, expiresAt := helper.SignedDownloadURL(grant.ID, file.ID)
+			links = append(links, fileLink{
+				ID:        file.ID,
+				FileName:  file.FileName,
+				Size:      file.Size,
+				URL:       url,
+				ExpiresAt: expiresAt.Unix(),
+			})
+		}
+
+		keys := []string{}
+		dc.DB.Model(&models.LicenseKey{}).Where("order_item_id = ?", grant.OrderItemID).Order("id").Pluck("key", &keys)
+
+		data = append(data, download{DownloadGrant: grant, Files: links, LicenseKeys: keys})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": data})
+}
+
+// Download serves a file through a signed, expiring link and counts the
+// download against the grant's limit
+func (dc *DigitalController) Download(c *gin.Context) {
+	grantID, err1 := strconv.ParseUint(c.Param("grantId"), 10, 64)
+	fileID, err2 := strconv.ParseUint(c.Param("fileId"), 10, 64)
+	expires, err3 := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err1 != nil || err2 != nil || err3 != nil ||
+		!helper.VerifyDownloadURL(uint(grantID), uint(fileID), expires, c.Query("signature")) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Download link is invalid or has expired"})
+		return
+	}
+
+	var grant models.DownloadGrant
+	if err := dc.DB.First(&grant, grantID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Download not found"})
+		return
+	}
+	var file models.ProductFile
+	if err := dc.DB.Where("id = ? AND product_id = ?", fileID, grant.ProductID).First(&file).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "File not found"})
+		return
+	}
+
+	result := dc.DB.Model(&models.DownloadGrant{}).
+		Where("id = ? AND revoked_at IS NULL AND (max_downloads = 0 OR download_count < max_downloads)", grant.ID).
+		UpdateColumn("download_count", gorm.Expr("download_count + 1"))
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start download"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Download limit reached"})
+		return
+	}
+
+	c.FileAttachment(file.StoragePath, file.FileName)
+}
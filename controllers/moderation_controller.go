@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ModerationController struct {
+	DB *gorm.DB
+}
+
+func NewModerationController(DB *gorm.DB) ModerationController {
+	return ModerationController{DB}
+}
+
+// GetReviewQueue lists products waiting for review, oldest submission first
+func (mc *ModerationController) GetReviewQueue(c *gin.Context) {
+	var products []models.Product
+	if err := mc.DB.
+		Preload("Category").
+		Preload("User").
+		Preload("Vendor").
+		Where("status = ?", models.ProductStatusPendingReview).
+		Order("updated_at ASC").
+		Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch review queue"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": products, "moderation_enabled": helper.ModerationEnabled()})
+}
+
+// ApproveProduct publishes a product from the review queue
+func (mc *ModerationController) ApproveProduct(c *gin.Context) {
+	var req struct {
+		Note string `json:"note"`
+	}
+	// the note is optional, so an empty body is fine
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	mc.review(c, models.ModerationApproved, "published", req.Note)
+}
+
+// RejectProduct sends a product back to draft with the reviewer's feedback
+func (mc *ModerationController) RejectProduct(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mc.review(c, models.ModerationRejected, "draft", req.Reason)
+}
+
+// GetModerationHistory lists the review decisions of a product
+func (mc *ModerationController) GetModerationHistory(c *gin.Context) {
+	var history []models.ProductModeration
+	if err := mc.DB.Where("product_id = ?", c.Param("id")).
+		Order("id DESC").
+		Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch moderation history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
+
+func (mc *ModerationController) review(c *gin.Context, decision, newStatus, note string) {
+	reviewerID := c.MustGet("user_id").(uint)
+	id := c.Param("id")
+
+	var product models.Product
+	if err := mc.DB.Preload("Vendor").First(&product, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	if product.Status != models.ProductStatusPendingReview {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product is not waiting for review"})
+		return
+	}
+
+	now := time.Now()
+	err := mc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&product).Updates(map[string]interface{}{
+			"status":          newStatus,
+			"moderation_note": note,
+			"reviewed_by":     reviewerID,
+			"reviewed_at":     now,
+		}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Create(&models.ProductModeration{
+			ProductID:  product.ID,
+			Decision:   decision,
+			Reason:     note,
+			ReviewerID: reviewerID,
+		}).Error; err != nil {
+			return err
+		}
+
+		oldValJSON, _ := json.Marshal(map[string]string{"status": models.ProductStatusPendingReview})
+		newValJSON, _ := json.Marshal(map[string]string{"status": newStatus, "decision": decision, "reason": note})
+		return tx.Create(&models.AuditLog{
+			ActorID:  &reviewerID,
+			Action:   "moderate_product",
+			Resource: "product:" + id,
+			OldValue: string(oldValJSON),
+			NewValue: string(newValJSON),
+		}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save review decision"})
+		return
+	}
+
+	title := "Product approved: " + product.Name
+	message := fmt.Sprintf("%s was approved and is now published.", product.Name)
+	if decision == models.ModerationRejected {
+		title = "Product rejected: " + product.Name
+		message = fmt.Sprintf("%s was not approved for publishing. Feedback: %s", product.Name, note)
+	}
+	helper.Notify(mc.DB, product.Vendor.UserID, "product_"+decision, title, message,
+		fmt.Sprintf("product:%d", product.ID), true)
+
+	mc.DB.Preload("Category").Preload("Vendor").First(&product, product.ID)
+	c.JSON(http.StatusOK, product)
+}
@@ -0,0 +1,80 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type NotificationController struct {
+	DB *gorm.DB
+}
+
+func NewNotificationController(DB *gorm.DB) NotificationController {
+	return NotificationController{DB}
+}
+
+// GetNotifications lists the user's in-app notifications, newest first
+func (nc *NotificationController) GetNotifications(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	page, limit := pagination(c)
+
+	query := nc.DB.Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&notifications).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
+		return
+	}
+
+	var unread int64
+	nc.DB.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread)
+
+	c.JSON(http.StatusOK, gin.H{"data": notifications, "unread": unread})
+}
+
+// MarkNotificationRead marks a single notification as read
+func (nc *NotificationController) MarkNotificationRead(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	id := c.Param("id")
+
+	var notification models.Notification
+	if err := nc.DB.Where("id = ? AND user_id = ?", id, userID).First(&notification).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+
+	if notification.ReadAt == nil {
+		now := time.Now()
+		notification.ReadAt = &now
+		if err := nc.DB.Save(&notification).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification"})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": notification})
+}
+
+// MarkAllNotificationsRead marks every unread notification of the user as read
+func (nc *NotificationController) MarkAllNotificationsRead(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	if err := nc.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now()).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read"})
+}
@@ -1,13 +1,17 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"time"
 
+	"github.com/abdullahalsazib/e-com-backend/helper"
 	"github.com/abdullahalsazib/e-com-backend/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderController struct {
@@ -29,7 +33,7 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 	}
 
 	var cart models.Cart
-	if err := oc.DB.Preload("CartItems.Product").Where("user_id = ?", userID).First(&cart).Error; err != nil {
+	if err := helper.PreloadCartItems(oc.DB).Where("user_id = ?", userID).First(&cart).Error; err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Cart is empty"})
 		return
 	}
@@ -39,12 +43,48 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		return
 	}
 
+	// the cart may have changed since the user last saw it; any change is
+	// sent back for review instead of ordering something other than expected
+	warnings, err := helper.ValidateCart(oc.DB, &cart)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to validate cart"})
+		return
+	}
+	if len(warnings) > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":    "Your cart has changed, please review it before placing the order",
+			"warnings": warnings,
+			"data":     cart,
+		})
+		return
+	}
+
+	// only orders made entirely of digital products need no shipping address
+	if request.ShippingAddress == "" {
+		for _, item := range cart.CartItems {
+			if item.Product.Type != models.ProductTypeDigital {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Shipping address is required for physical products"})
+				return
+			}
+		}
+	}
+
 	var totalAmount float64
+	// an online payment has until reservedUntil; orders paid on delivery keep
+	// their stock without expiry
+	reservedUntil := time.Now().Add(helper.ReservationTTL())
+	payOnDelivery := helper.PaysOnDelivery(request.PaymentMethod)
+	reservationStatus := models.ReservationActive
+	if payOnDelivery {
+		reservationStatus = models.ReservationCommitted
+	}
 	order := models.Order{
 		UserID:          userID,
 		Status:          models.OrderStatusPending,
 		ShippingAddress: request.ShippingAddress,
 		PaymentMethod:   request.PaymentMethod,
+
+		RecoveryReminderID: helper.CartRecoveryReminder(oc.DB, cart.ID, request.RecoveryToken),
 	}
 
 	tx := oc.DB.Begin()
@@ -55,13 +95,15 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		}
 	}()
 
+	productNames := make(map[uint]string)
+	bundles := make(map[uint]bool)
+	digital := make(map[uint]bool)
+	vendorOf := make(map[uint]uint)
 	for _, item := range cart.CartItems {
-		if item.Product.Stock < item.Quantity {
-			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Not enough stock for %s", item.Product.Name)})
-			return
-		}
-
+		productNames[item.ProductID] = item.Product.Name
+		vendorOf[item.ProductID] = item.Product.VendorID
+		bundles[item.ProductID] = item.Product.Type == models.ProductTypeBundle
+		digital[item.ProductID] = item.Product.Type == models.ProductTypeDigital
 		totalAmount += item.Product.Price * float64(item.Quantity)
 
 		order.Items = append(order.Items, models.OrderItem{
@@ -69,15 +111,12 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 			Quantity:  item.Quantity,
 			UnitPrice: item.Product.Price,
 		})
-
-		if err := tx.Model(&item.Product).Update("stock", item.Product.Stock-item.Quantity).Error; err != nil {
-			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
-			return
-		}
 	}
 
 	order.TotalAmount = totalAmount
+	if !payOnDelivery {
+		order.ReservedUntil = &reservedUntil
+	}
 
 	if err := tx.Create(&order).Error; err != nil {
 		tx.Rollback()
@@ -85,13 +124,105 @@ func (oc *OrderController) CreateOrder(c *gin.Context) {
 		return
 	}
 
-	if err := tx.Where("cart_id = ?", cart.ID).Delete(&models.CartItem{}).Error; err != nil {
+	if err := helper.RecordOrderStatus(tx, order.ID, nil, "", order.Status, &userID, "order placed"); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+
+	// each vendor fulfils its own part of the order
+	if err := helper.SplitOrderByVendor(tx, &order, vendorOf); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+
+	// take the stock atomically and hold it while payment is pending; a bundle
+	// takes the stock of its components, digital products have none
+	stockProductIDs := []uint{}
+	for i := range order.Items {
+		item := &order.Items[i]
+		if digital[item.ProductID] {
+			continue
+		}
+		takes := []models.OrderItemComponent{{ProductID: item.ProductID, Quantity: item.Quantity}}
+		if bundles[item.ProductID] {
+			components, err := helper.BundleComponents(tx, item.ProductID)
+			if err != nil || len(components) == 0 {
+				tx.Rollback()
+				c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("%s is not available", productNames[item.ProductID])})
+				return
+			}
+			takes = takes[:0]
+			for _, component := range components {
+				takes = append(takes, models.OrderItemComponent{
+					OrderItemID: item.ID,
+					ProductID:   component.ComponentID,
+					Quantity:    component.Quantity * item.Quantity,
+				})
+			}
+			if err := tx.Create(&takes).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+				return
+			}
+			item.Components = takes
+		}
+
+		for _, take := range takes {
+			err := helper.ApplyStockMovement(tx, &models.StockMovement{
+				ProductID: take.ProductID,
+				Type:      models.StockMovementSale,
+				Quantity:  -take.Quantity,
+				ActorID:   &userID,
+				Reason:    "order placed",
+				Reference: fmt.Sprintf("order:%d", order.ID),
+			})
+			if errors.Is(err, helper.ErrInsufficientStock) {
+				tx.Rollback()
+				c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("Not enough stock for %s", productNames[item.ProductID])})
+				return
+			}
+			if err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update product stock"})
+				return
+			}
+
+			if err := tx.Create(&models.StockReservation{
+				ProductID: take.ProductID,
+				OrderID:   order.ID,
+				Quantity:  take.Quantity,
+				Status:    reservationStatus,
+				ExpiresAt: reservedUntil,
+			}).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reserve stock"})
+				return
+			}
+			stockProductIDs = append(stockProductIDs, take.ProductID)
+		}
+	}
+
+	if err := tx.Where("cart_id = ? AND saved_for_later = ?", cart.ID, false).Delete(&models.CartItem{}).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
 		return
 	}
 
-	tx.Commit()
+	// checking out ends the reminder sequence
+	if err := helper.TouchCart(tx, cart.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear cart"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create order"})
+		return
+	}
+
+	helper.CheckLowStock(oc.DB, stockProductIDs...)
 
 	c.JSON(http.StatusCreated, gin.H{"data": order})
 }
@@ -107,7 +238,8 @@ func (oc *OrderController) GetOrders(c *gin.Context) {
 	limit, _ := strconv.Atoi(limitStr)
 	offset := (page - 1) * limit
 
-	if err := oc.DB.Preload("Items.Product").Where("user_id = ?", userID).
+	if err := oc.DB.Preload("Items.Product").Preload("Items.Components.Product").
+		Preload("VendorOrders.Vendor").Preload("VendorOrders.Items.Product").Where("user_id = ?", userID).
 		Limit(limit).Offset(offset).Find(&orders).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
 		return
@@ -122,7 +254,10 @@ func (oc *OrderController) GetOrder(c *gin.Context) {
 	orderID := c.Param("orderId")
 
 	var order models.Order
-	if err := oc.DB.Preload("Items.Product").Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+	if err := oc.DB.Preload("Items.Product").Preload("Items.Components.Product").
+		Preload("VendorOrders.Vendor").Preload("VendorOrders.Items.Product").
+		Preload("StatusHistory", func(db *gorm.DB) *gorm.DB { return db.Order("created_at, id") }).
+		Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		} else {
@@ -134,10 +269,19 @@ func (oc *OrderController) GetOrder(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": order})
 }
 
-// UpdateOrderStatus updates an order's status (admin only)
+// UpdateOrderStatus moves an order to another status (admin only). Only the
+// transitions of the order state machine are allowed.
 func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
-	role, _ := c.Get("role")
-	if role != "admin" && role != "superadmin" {
+	roles, _ := c.Get("role")
+	roleSlice, _ := roles.([]string)
+	isAdmin := false
+	for _, role := range roleSlice {
+		if role == "admin" || role == "superadmin" {
+			isAdmin = true
+			break
+		}
+	}
+	if !isAdmin {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
 		return
 	}
@@ -149,21 +293,69 @@ func (oc *OrderController) UpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
+	actorID := c.MustGet("user_id").(uint)
 	var order models.Order
-	if err := oc.DB.First(&order, orderID).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
+	var oldStatus models.OrderStatus
+	var shortOfKeys []uint
+	err := oc.DB.Transaction(func(tx *gorm.DB) error {
+		// the lock keeps the reservation worker and customer cancels out
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return err
+		}
+		oldStatus = order.Status
+		note := request.Note
+		if note == "" {
+			note = "status changed by admin"
+		}
+		if err := helper.SetOrderStatus(tx, &order, request.Status, &actorID, note); err != nil {
+			return err
+		}
+		if err := helper.CascadeOrderStatus(tx, order.ID, request.Status, &actorID, note); err != nil {
+			return err
+		}
+
+		switch {
+		case request.Status == models.OrderStatusCancelled:
+			if err := helper.RevokeDigitalItems(tx, order.ID); err != nil {
+				return err
+			}
+			return helper.ReleaseOrderStock(tx, &order, &actorID, "order cancelled by admin")
+		case request.Status == models.OrderStatusRefunded:
+			if err := helper.RevokeDigitalItems(tx, order.ID); err != nil {
+				return err
+			}
+			if request.Restock != nil && !*request.Restock {
+				return nil
+			}
+			return helper.RestockRefundedOrder(tx, &order, &actorID, "order refunded")
+		case helper.IsUnpaid(oldStatus) && !helper.IsUnpaid(request.Status):
+			// the order went ahead, so the held stock is now sold and
+			// digital items can be delivered
+			if err := helper.CommitReservations(tx, order.ID); err != nil {
+				return err
+			}
+			short, err := helper.FulfillDigitalItems(tx, &order)
+			shortOfKeys = short
+			return err
 		}
+		return nil
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
-
-	order.Status = request.Status
-	if err := oc.DB.Save(&order).Error; err != nil {
+	if errors.Is(err, helper.ErrInvalidTransition) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":               fmt.Sprintf("A %s order can't be moved to %s", oldStatus, request.Status),
+			"allowed_transitions": helper.OrderTransitions(oldStatus),
+		})
+		return
+	}
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order status"})
 		return
 	}
+	notifyLicenseKeyShortage(oc.DB, order.ID, shortOfKeys)
 
 	c.JSON(http.StatusOK, gin.H{"data": order})
 }
@@ -174,25 +366,53 @@ func (oc *OrderController) CancelOrder(c *gin.Context) {
 	orderID := c.Param("orderId")
 
 	var order models.Order
-	if err := oc.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
-		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
-		} else {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
+	errNotPending := errors.New("order is not pending")
+
+	// cancel the order and put its reserved stock back; the row lock keeps the
+	// reservation worker from releasing the same stock concurrently
+	err := oc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+			return err
+		}
+		if !helper.IsUnpaid(order.Status) {
+			return errNotPending
 		}
+		if err := helper.SetOrderStatus(tx, &order, models.OrderStatusCancelled, &userID, "order cancelled by customer"); err != nil {
+			return err
+		}
+		if err := helper.CascadeOrderStatus(tx, order.ID, models.OrderStatusCancelled, &userID, "order cancelled by customer"); err != nil {
+			return err
+		}
+		return helper.ReleaseOrderStock(tx, &order, &userID, "order cancelled by customer")
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
 		return
 	}
-
-	if order.Status != models.OrderStatusPending {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Only pending orders can be cancelled"})
+	if err == errNotPending {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Only unpaid orders can be cancelled"})
 		return
 	}
-
-	order.Status = models.OrderStatusCancelled
-	if err := oc.DB.Save(&order).Error; err != nil {
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel order"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Order cancelled successfully"})
 }
+
+// notifyLicenseKeyShortage tells vendors that a paid order could not get all
+// of its license keys
+func notifyLicenseKeyShortage(db *gorm.DB, orderID uint, productIDs []uint) {
+	for _, productID := range productIDs {
+		var product models.Product
+		if err := db.Preload("Vendor").First(&product, productID).Error; err != nil {
+			continue
+		}
+		helper.Notify(db, product.Vendor.UserID, "license_keys_exhausted",
+			"License keys ran out",
+			fmt.Sprintf("Order #%d could not get all license keys for %s. Add keys to the pool to deliver them.", orderID, product.Name),
+			fmt.Sprintf("product:%d", product.ID), true)
+	}
+}
@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type PriceController struct {
+	DB *gorm.DB
+}
+
+func NewPriceController(DB *gorm.DB) PriceController {
+	return PriceController{DB}
+}
+
+// CreatePriceSchedule schedules a future price (optionally with an end) for a vendor's product
+func (pc *PriceController) CreatePriceSchedule(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	product, ok := vendorProduct(pc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var request models.CreatePriceScheduleRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if request.EndsAt != nil && !request.EndsAt.After(request.StartsAt) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at must be after starts_at"})
+		return
+	}
+	if request.EndsAt != nil && request.EndsAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ends_at is in the past"})
+		return
+	}
+
+	// schedules of a product must not overlap; an open end overlaps everything after it
+	var overlapping int64
+	query := pc.DB.Model(&models.PriceSchedule{}).
+		Where("product_id = ? AND status IN ?", product.ID,
+			[]string{models.PriceScheduleScheduled, models.PriceScheduleActive}).
+		Where("ends_at IS NULL OR ends_at > ?", request.StartsAt)
+	if request.EndsAt != nil {
+		query = query.Where("starts_at < ?", *request.EndsAt)
+	}
+	if err := query.Count(&overlapping).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check existing schedules"})
+		return
+	}
+	if overlapping > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Schedule overlaps an existing price schedule"})
+		return
+	}
+
+	schedule := models.PriceSchedule{
+		ProductID: product.ID,
+		Price:     request.Price,
+		StartsAt:  request.StartsAt,
+		EndsAt:    request.EndsAt,
+		Status:    models.PriceScheduleScheduled,
+		CreatedBy: userID,
+	}
+	if err := pc.DB.Create(&schedule).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create price schedule"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": schedule})
+}
+
+// GetPriceSchedules lists the price schedules of a vendor's product
+func (pc *PriceController) GetPriceSchedules(c *gin.Context) {
+	product, ok := vendorProduct(pc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var schedules []models.PriceSchedule
+	if err := pc.DB.Where("product_id = ?", product.ID).
+		Order("starts_at DESC").
+		Find(&schedules).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price schedules"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": schedules})
+}
+
+// CancelPriceSchedule cancels a schedule; an active one restores the original
+// price unless the price was changed by hand during the sale
+func (pc *PriceController) CancelPriceSchedule(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	product, ok := vendorProduct(pc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var schedule models.PriceSchedule
+	if err := pc.DB.Where("id = ? AND product_id = ?", c.Param("scheduleId"), product.ID).
+		First(&schedule).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Price schedule not found"})
+		return
+	}
+
+	if schedule.Status != models.PriceScheduleScheduled && schedule.Status != models.PriceScheduleActive {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Price schedule already " + schedule.Status})
+		return
+	}
+
+	keptPrice := false
+	err := pc.DB.Transaction(func(tx *gorm.DB) error {
+		if schedule.Status == models.PriceScheduleActive {
+			restored, err := helper.RestoreSchedulePrice(tx, &schedule, &userID)
+			if err != nil {
+				return err
+			}
+			keptPrice = !restored && schedule.OriginalPrice != nil
+		}
+		return tx.Model(&schedule).Update("status", models.PriceScheduleCancelled).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to cancel price schedule"})
+		return
+	}
+
+	if keptPrice {
+		c.JSON(http.StatusOK, gin.H{"message": "Price schedule cancelled, the price changed during the sale was kept"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Price schedule cancelled"})
+}
+
+// GetPriceHistoryVendor lists the price changes of a vendor's product
+func (pc *PriceController) GetPriceHistoryVendor(c *gin.Context) {
+	product, ok := vendorProduct(pc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+	pc.priceHistory(c, product.ID)
+}
+
+// GetPriceHistorySuperadmin lists the price changes of any product
+func (pc *PriceController) GetPriceHistorySuperadmin(c *gin.Context) {
+	var product models.Product
+	if err := pc.DB.First(&product, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Product not found"})
+		return
+	}
+	pc.priceHistory(c, product.ID)
+}
+
+func (pc *PriceController) priceHistory(c *gin.Context, productID uint) {
+	var history []models.ProductPriceHistory
+	if err := pc.DB.Where("product_id = ?", productID).
+		Order("id DESC").
+		Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": history})
+}
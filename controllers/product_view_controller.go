@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ProductViewController struct {
+	DB *gorm.DB
+}
+
+func NewProductViewController(DB *gorm.DB) ProductViewController {
+	return ProductViewController{DB}
+}
+
+// clientID returns the anonymous visitor ID sent in the X-Client-ID header
+func clientID(c *gin.Context) string {
+	id := c.GetHeader("X-Client-ID")
+	if len(id) > 64 {
+		return ""
+	}
+	return id
+}
+
+// recordProductView records a product view of the current user or anonymous
+// visitor in the background
+func recordProductView(db *gorm.DB, c *gin.Context, productID uint) {
+	var userID *uint
+	if id, ok := c.Get("user_id"); ok {
+		uid := id.(uint)
+		userID = &uid
+	}
+	client := clientID(c)
+
+	go func() {
+		if err := helper.RecordProductView(db, productID, userID, client); err != nil {
+			log.Printf("Failed to record view of product %d: %v", productID, err)
+		}
+	}()
+}
+
+// GetRecentlyViewed lists the user's recently viewed products that are still
+// visible, latest first
+func (vc *ProductViewController) GetRecentlyViewed(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > helper.RecentlyViewedLimit() {
+		limit = 20
+	}
+
+	var views []models.ProductView
+	if err := vc.DB.
+		Preload("Product").
+		Preload("Product.Category").
+		Joins("JOIN products ON products.id = product_views.product_id AND products.deleted_at IS NULL").
+		Scopes(helper.CustomerVisible).
+		Where("product_views.viewer = ?", helper.UserViewer(userID)).
+		Order("product_views.viewed_at DESC").
+		Limit(limit).
+		Find(&views).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recently viewed products"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": views})
+}
+
+// ClearRecentlyViewed deletes the user's browsing history
+func (vc *ProductViewController) ClearRecentlyViewed(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	if err := vc.DB.Where("viewer = ?", helper.UserViewer(userID)).
+		Delete(&models.ProductView{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear browsing history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Browsing history cleared"})
+}
+
+// GetViewAnalytics reports the views of the vendor's products over the last
+// days (default 30): totals per product and a daily series
+func (vc *ProductViewController) GetViewAnalytics(c *gin.Context) {
+	vendor, ok := currentVendor(vc.DB, c)
+	if !ok {
+		return
+	}
+
+	days, _ := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if days < 1 || days > 365 {
+		days = 30
+	}
+	since := time.Now().AddDate(0, 0, -days+1).Truncate(24 * time.Hour)
+
+	type productRow struct {
+		ProductID uint   `json:"product_id"`
+		Name      string `json:"name"`
+		Views     int    `json:"views"`
+		ViewCount int    `json:"all_time_views"`
+	}
+	var products []productRow
+	if err := vc.DB.Table("products").
+		Select(`products.id AS product_id, products.name, products.view_count,
+			COALESCE(SUM(product_view_stats.views), 0) AS views`).
+		Joins("LEFT JOIN product_view_stats ON product_view_stats.product_id = products.id AND product_view_stats.day >= ?", since).
+		Where("products.vendor_id = ? AND products.deleted_at IS NULL", vendor.ID).
+		Group("products.id, products.name, products.view_count").
+		Order("views DESC, products.id").
+		Scan(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build view analytics"})
+		return
+	}
+
+	type dayRow struct {
+		Day   time.Time `json:"day"`
+		Views int       `json:"views"`
+	}
+	var daily []dayRow
+	if err := vc.DB.Table("product_view_stats").
+		Select("product_view_stats.day, SUM(product_view_stats.views) AS views").
+		Joins("JOIN products ON products.id = product_view_stats.product_id").
+		Where("products.vendor_id = ? AND product_view_stats.day >= ?", vendor.ID, since).
+		Group("product_view_stats.day").
+		Order("product_view_stats.day").
+		Scan(&daily).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build view analytics"})
+		return
+	}
+
+	total := 0
+	for _, row := range daily {
+		total += row.Views
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"days":     days,
+		"total":    total,
+		"products": products,
+		"daily":    daily,
+	})
+}
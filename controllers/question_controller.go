@@ -0,0 +1,373 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type QuestionController struct {
+	DB *gorm.DB
+}
+
+func NewQuestionController(DB *gorm.DB) QuestionController {
+	return QuestionController{DB}
+}
+
+// GetProductQuestions lists visible questions and answers of a published product
+func (qc *QuestionController) GetProductQuestions(c *gin.Context) {
+	productID := c.Param("id")
+
+	var product models.Product
+	if err := qc.DB.Scopes(helper.CustomerVisible).Where("products.id = ?", productID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found or not published"})
+		return
+	}
+
+	page, limit := pagination(c)
+
+	var questions []models.ProductQuestion
+	if err := qc.DB.
+		Preload("User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Preload("Answers", func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", models.QAStatusVisible).
+				Order("is_vendor DESC, upvotes DESC, created_at ASC")
+		}).
+		Preload("Answers.User", func(db *gorm.DB) *gorm.DB { return db.Select("id", "name") }).
+		Where("product_id = ? AND status = ?", product.ID, models.QAStatusVisible).
+		Order("created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&questions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch questions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": questions})
+}
+
+// AskQuestion lets a logged-in customer ask a question about a published product
+func (qc *QuestionController) AskQuestion(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	productID := c.Param("id")
+
+	var request models.AskQuestionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var product models.Product
+	if err := qc.DB.Preload("Vendor").Scopes(helper.CustomerVisible).Where("products.id = ?", productID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found or not published"})
+		return
+	}
+
+	question := models.ProductQuestion{
+		ProductID: product.ID,
+		UserID:    userID,
+		Body:      request.Body,
+		Status:    models.QAStatusVisible,
+	}
+	if err := qc.DB.Create(&question).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit question"})
+		return
+	}
+
+	// let the vendor know a question arrived
+	if product.Vendor.UserID != 0 && product.Vendor.UserID != userID {
+		helper.Notify(qc.DB, product.Vendor.UserID, "product_question",
+			"New question about "+product.Name,
+			fmt.Sprintf("A customer asked: %q", request.Body),
+			fmt.Sprintf("product:%d", product.ID), true)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": question})
+}
+
+// AnswerQuestion lets the owning vendor or a verified buyer answer a question
+func (qc *QuestionController) AnswerQuestion(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	questionID := c.Param("questionId")
+
+	var request models.AnswerQuestionRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var question models.ProductQuestion
+	if err := qc.DB.Preload("Product.Vendor").
+		Where("id = ? AND status = ?", questionID, models.QAStatusVisible).
+		First(&question).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	isVendor := question.Product != nil && question.Product.Vendor.UserID == userID
+	isVerifiedBuyer := false
+	if !isVendor {
+		var count int64
+		qc.DB.Model(&models.OrderItem{}).
+			Joins("JOIN orders ON orders.id = order_items.order_id").
+			Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ?",
+				userID, models.OrderStatusDelivered, question.ProductID).
+			Count(&count)
+		isVerifiedBuyer = count > 0
+	}
+
+	if !isVendor && !isVerifiedBuyer {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the vendor or verified buyers can answer"})
+		return
+	}
+
+	answer := models.ProductAnswer{
+		QuestionID:      question.ID,
+		UserID:          userID,
+		Body:            request.Body,
+		IsVendor:        isVendor,
+		IsVerifiedBuyer: isVerifiedBuyer,
+		Status:          models.QAStatusVisible,
+	}
+	if err := qc.DB.Create(&answer).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit answer"})
+		return
+	}
+
+	if question.UserID != userID {
+		helper.Notify(qc.DB, question.UserID, "question_answered",
+			"Your question was answered",
+			fmt.Sprintf("Your question %q received a new answer", question.Body),
+			fmt.Sprintf("product:%d", question.ProductID), false)
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": answer})
+}
+
+// UpvoteAnswer adds the user's upvote to an answer (once per user)
+func (qc *QuestionController) UpvoteAnswer(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	answerID, err := strconv.Atoi(c.Param("answerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer ID"})
+		return
+	}
+
+	var answer models.ProductAnswer
+	if err := qc.DB.Where("id = ? AND status = ?", answerID, models.QAStatusVisible).First(&answer).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Answer not found"})
+		return
+	}
+
+	err = qc.DB.Transaction(func(tx *gorm.DB) error {
+		vote := models.AnswerVote{AnswerID: answer.ID, UserID: userID}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&vote)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrDuplicatedKey
+		}
+		return tx.Model(&answer).UpdateColumn("upvotes", gorm.Expr("upvotes + 1")).Error
+	})
+	if err == gorm.ErrDuplicatedKey {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already upvoted this answer"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to upvote answer"})
+		return
+	}
+
+	qc.DB.First(&answer, answer.ID)
+	c.JSON(http.StatusOK, gin.H{"data": answer})
+}
+
+// ReportQuestion flags a question for superadmin review
+func (qc *QuestionController) ReportQuestion(c *gin.Context) {
+	questionID, err := strconv.Atoi(c.Param("questionId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid question ID"})
+		return
+	}
+
+	var question models.ProductQuestion
+	if err := qc.DB.First(&question, questionID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Question not found"})
+		return
+	}
+
+	id := question.ID
+	qc.createReport(c, &models.QAReport{QuestionID: &id})
+}
+
+// ReportAnswer flags an answer for superadmin review
+func (qc *QuestionController) ReportAnswer(c *gin.Context) {
+	answerID, err := strconv.Atoi(c.Param("answerId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid answer ID"})
+		return
+	}
+
+	var answer models.ProductAnswer
+	if err := qc.DB.First(&answer, answerID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Answer not found"})
+		return
+	}
+
+	id := answer.ID
+	qc.createReport(c, &models.QAReport{AnswerID: &id})
+}
+
+func (qc *QuestionController) createReport(c *gin.Context, report *models.QAReport) {
+	var request models.ReportRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	report.ReporterID = c.MustGet("user_id").(uint)
+	report.Reason = request.Reason
+	report.Status = models.ReportStatusOpen
+
+	if err := qc.DB.Create(report).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to submit report"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Report submitted", "data": report})
+}
+
+// ListReports lists Q&A reports for moderation (superadmin only)
+func (qc *QuestionController) ListReports(c *gin.Context) {
+	status := c.DefaultQuery("status", models.ReportStatusOpen)
+
+	var reports []models.QAReport
+	query := qc.DB.Order("created_at ASC")
+	if status != "all" {
+		query = query.Where("status = ?", status)
+	}
+	if err := query.Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reports"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": reports})
+}
+
+// ResolveReport hides the reported content or dismisses the report (superadmin only)
+func (qc *QuestionController) ResolveReport(c *gin.Context) {
+	actorID := c.MustGet("user_id").(uint)
+	id := c.Param("id")
+
+	var request struct {
+		Action string `json:"action" binding:"required,oneof=hide dismiss"`
+		Note   string `json:"note"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var report models.QAReport
+	if err := qc.DB.First(&report, id).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+	if report.Status != models.ReportStatusOpen {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Report already handled"})
+		return
+	}
+
+	err := qc.DB.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		report.ResolvedBy = &actorID
+		report.ResolvedAt = &now
+		report.Note = request.Note
+		report.Status = models.ReportStatusDismissed
+
+		if request.Action == "hide" {
+			report.Status = models.ReportStatusResolved
+			if err := hideReportedContent(tx, &report); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&report).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve report"})
+		return
+	}
+
+	newValJSON, _ := json.Marshal(map[string]string{"action": request.Action, "note": request.Note})
+	qc.DB.Create(&models.AuditLog{
+		ActorID:  &actorID,
+		Action:   "resolve_qa_report",
+		Resource: "qa_report:" + id,
+		NewValue: string(newValJSON),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"data": report})
+}
+
+// SetQuestionStatus hides or restores a question (superadmin only)
+func (qc *QuestionController) SetQuestionStatus(c *gin.Context) {
+	qc.setStatus(c, &models.ProductQuestion{}, "question")
+}
+
+// SetAnswerStatus hides or restores an answer (superadmin only)
+func (qc *QuestionController) SetAnswerStatus(c *gin.Context) {
+	qc.setStatus(c, &models.ProductAnswer{}, "answer")
+}
+
+func (qc *QuestionController) setStatus(c *gin.Context, model interface{}, resource string) {
+	actorID := c.MustGet("user_id").(uint)
+	id := c.Param("id")
+
+	var request struct {
+		Status string `json:"status" binding:"required,oneof=visible hidden"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result := qc.DB.Model(model).Where("id = ?", id).Update("status", request.Status)
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update status"})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+		return
+	}
+
+	newValJSON, _ := json.Marshal(map[string]string{"status": request.Status})
+	qc.DB.Create(&models.AuditLog{
+		ActorID:  &actorID,
+		Action:   "update_" + resource + "_status",
+		Resource: resource + ":" + id,
+		NewValue: string(newValJSON),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "Status updated successfully"})
+}
+
+func hideReportedContent(tx *gorm.DB, report *models.QAReport) error {
+	if report.QuestionID != nil {
+		return tx.Model(&models.ProductQuestion{}).Where("id = ?", *report.QuestionID).
+			Update("status", models.QAStatusHidden).Error
+	}
+	if report.AnswerID != nil {
+		return tx.Model(&models.ProductAnswer{}).Where("id = ?", *report.AnswerID).
+			Update("status", models.QAStatusHidden).Error
+	}
+	return nil
+}
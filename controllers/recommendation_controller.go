@@ -0,0 +1,95 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type RecommendationController struct {
+	DB *gorm.DB
+}
+
+func NewRecommendationController(DB *gorm.DB) RecommendationController {
+	return RecommendationController{DB}
+}
+
+// GetRecommendations serves the precomputed "frequently bought together" and
+// related products of a product. Products that are hidden or out of stock
+// right now are skipped, and related products never repeat a bought together one.
+func (rc *RecommendationController) GetRecommendations(c *gin.Context) {
+	var product models.Product
+	if err := rc.DB.Scopes(helper.CustomerVisible, helper.ProductIDOrSlug(c.Param("id"))).
+		First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found or not published"})
+		return
+	}
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "8"))
+	if limit < 1 || limit > 20 {
+		limit = 8
+	}
+
+	var recommendations []models.ProductRecommendation
+	if err := rc.DB.Model(&models.ProductRecommendation{}).
+		Select("product_recommendations.*").
+		Joins("JOIN products ON products.id = product_recommendations.recommended_id AND products.deleted_at IS NULL").
+		Scopes(helper.CustomerVisible, helper.InStock).
+		Where("product_recommendations.product_id = ?", product.ID).
+		Order("product_recommendations.kind, product_recommendations.rank").
+		Find(&recommendations).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations"})
+		return
+	}
+
+	boughtTogether := []uint{}
+	similar := []uint{}
+	seen := map[uint]bool{}
+	for _, kind := range []string{models.RecommendationBoughtTogether, models.RecommendationSimilar} {
+		for _, recommendation := range recommendations {
+			if recommendation.Kind != kind || seen[recommendation.RecommendedID] {
+				continue
+			}
+			if kind == models.RecommendationBoughtTogether && len(boughtTogether) < limit {
+				boughtTogether = append(boughtTogether, recommendation.RecommendedID)
+				seen[recommendation.RecommendedID] = true
+			} else if kind == models.RecommendationSimilar && len(similar) < limit {
+				similar = append(similar, recommendation.RecommendedID)
+				seen[recommendation.RecommendedID] = true
+			}
+		}
+	}
+
+	ids := append(append([]uint{}, boughtTogether...), similar...)
+	var products []models.Product
+	if len(ids) > 0 {
+		if err := rc.DB.Preload("Category").Preload("Tags").Preload("Vendor").
+			Where("id IN ?", ids).Find(&products).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch recommendations"})
+			return
+		}
+	}
+	byID := make(map[uint]models.Product, len(products))
+	for _, p := range products {
+		byID[p.ID] = p
+	}
+	ordered := func(ids []uint) []models.Product {
+		list := make([]models.Product, 0, len(ids))
+		for _, id := range ids {
+			if p, ok := byID[id]; ok {
+				list = append(list, p)
+			}
+		}
+		return list
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"product_id":                 product.ID,
+		"frequently_bought_together": ordered(boughtTogether),
+		"related":                    ordered(similar),
+	})
+}
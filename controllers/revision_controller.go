@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RevisionController struct {
+	DB *gorm.DB
+}
+
+func NewRevisionController(DB *gorm.DB) RevisionController {
+	return RevisionController{DB}
+}
+
+// GetRevisions lists the revisions of a vendor's product, newest first. The
+// changes of a revision are the fields the following update changed.
+func (rc *RevisionController) GetRevisions(c *gin.Context) {
+	product, ok := vendorProduct(rc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	page, limit := pagination(c)
+
+	var total int64
+	rc.DB.Model(&models.ProductRevision{}).Where("product_id = ?", product.ID).Count(&total)
+
+	// fetch one revision newer than the page to diff its last entry against
+	offset := (page - 1) * limit
+	query := rc.DB.Preload("Actor").Where("product_id = ?", product.ID).Order("version DESC")
+	var newer []models.ProductRevision
+	if offset > 0 {
+		if err := query.Session(&gorm.Session{}).Limit(1).Offset(offset - 1).Find(&newer).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+			return
+		}
+	}
+	var revisions []models.ProductRevision
+	if err := query.Limit(limit).Offset(offset).Find(&revisions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch revisions"})
+		return
+	}
+
+	next := helper.SnapshotOf(product)
+	if len(newer) > 0 {
+		snapshot, err := helper.ParseSnapshot(&newer[0])
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Corrupt revision snapshot"})
+			return
+		}
+		next = snapshot
+	}
+
+	type revisionResponse struct {
+		models.ProductRevision
+		Snapshot helper.ProductSnapshot `json:"snapshot"`
+		Changes  []helper.FieldChange   `json:"changes"`
+	}
+	data := make([]revisionResponse, 0, len(revisions))
+	for _, revision := range revisions {
+		snapshot, err := helper.ParseSnapshot(&revision)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Corrupt revision snapshot"})
+			return
+		}
+		data = append(data, revisionResponse{
+			ProductRevision: revision,
+			Snapshot:        snapshot,
+			Changes:         helper.DiffSnapshots(snapshot, next),
+		})
+		next = snapshot
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":  data,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+}
+
+// RollbackRevision restores the fields of a product from one of its revisions.
+// The current version is saved as a new revision first, so a rollback can be
+// undone too. Stock is not part of revisions and stays as it is.
+func (rc *RevisionController) RollbackRevision(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	product, ok := vendorProduct(rc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var revision models.ProductRevision
+	if err := rc.DB.Where("id = ? AND product_id = ?", c.Param("revisionId"), product.ID).First(&revision).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Revision not found"})
+		return
+	}
+	target, err := helper.ParseSnapshot(&revision)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Corrupt revision snapshot"})
+		return
+	}
+
+	var category models.Category
+	if err := rc.DB.First(&category, target.CategoryID).Error; err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "The revision's category no longer exists"})
+		return
+	}
+
+	var previous helper.ProductSnapshot
+	var changes []helper.FieldChange
+	err = rc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(product, product.ID).Error; err != nil {
+			return err
+		}
+		previous = helper.SnapshotOf(product)
+		changes = helper.DiffSnapshots(previous, target)
+		if len(changes) == 0 {
+			return nil
+		}
+		if err := helper.SaveRevision(tx, product.ID, previous, &userID, models.RevisionActionRollback); err != nil {
+			return err
+		}
+
+		if target.Status != product.Status {
+			clearPastSchedule(product)
+		}
+		renamed := target.Name != product.Name
+		product.Name = target.Name
+		product.Description = target.Description
+		product.MetaTitle = target.MetaTitle
+		product.MetaDescription = target.MetaDescription
+		product.ImageURL = target.ImageURL
+		product.CategoryID = target.CategoryID
+		product.Status = helper.PublishStatus(product.Status, target.Status)
+		rearmLowStock := target.ReorderThreshold != product.ReorderThreshold
+		if rearmLowStock {
+			product.ReorderThreshold = target.ReorderThreshold
+			product.LowStockAlertedAt = nil
+		}
+		if renamed {
+			if err := helper.AssignProductSlug(tx, product, ""); err != nil {
+				return err
+			}
+		}
+		if err := tx.Select(productEditColumns(rearmLowStock)).Save(product).Error; err != nil {
+			return err
+		}
+		return helper.ChangePrice(tx, product.ID, target.Price, &userID, models.PriceSourceRollback, nil)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to roll back product"})
+		return
+	}
+
+	if len(changes) == 0 {
+		c.JSON(http.StatusOK, gin.H{"message": "Product already matches this revision"})
+		return
+	}
+
+	oldValJSON, _ := json.Marshal(previous)
+	newValJSON, _ := json.Marshal(map[string]interface{}{
+		"revision_id": revision.ID,
+		"version":     revision.Version,
+		"snapshot":    target,
+	})
+	rc.DB.Create(&models.AuditLog{
+		ActorID:  &userID,
+		Action:   "rollback_product",
+		Resource: fmt.Sprintf("product:%d", product.ID),
+		OldValue: string(oldValJSON),
+		NewValue: string(newValJSON),
+	})
+
+	helper.CheckLowStock(rc.DB, product.ID)
+	if target.Price < previous.Price {
+		go func(productID uint) {
+			if err := helper.NotifyPriceDrops(rc.DB, productID); err != nil {
+				log.Printf("Failed to send price drop alerts for product %d: %v", productID, err)
+			}
+		}(product.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": fmt.Sprintf("Product rolled back to version %d", revision.Version),
+		"changes": changes,
+	})
+}
@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockController struct {
+	DB *gorm.DB
+}
+
+func NewStockController(DB *gorm.DB) StockController {
+	return StockController{DB}
+}
+
+// AdjustStock books a manual stock movement (adjustment, return or import) for a vendor's product
+func (sc *StockController) AdjustStock(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	product, ok := vendorProduct(sc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	var request models.AdjustStockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	movement := models.StockMovement{
+		ProductID: product.ID,
+		Type:      request.Type,
+		Quantity:  request.Quantity,
+		ActorID:   &userID,
+		Reason:    request.Reason,
+	}
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		return helper.ApplyStockMovement(tx, &movement)
+	})
+	if errors.Is(err, helper.ErrInsufficientStock) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Stock cannot go below zero"})
+		return
+	}
+	if errors.Is(err, helper.ErrBundleStock) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A bundle's stock follows its components"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to adjust stock"})
+		return
+	}
+	helper.CheckLowStock(sc.DB, product.ID)
+
+	c.JSON(http.StatusCreated, gin.H{"data": movement})
+}
+
+// GetStockHistory lists the ledger movements of a vendor's product, newest first
+func (sc *StockController) GetStockHistory(c *gin.Context) {
+	product, ok := vendorProduct(sc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	page, limit := pagination(c)
+
+	query := sc.DB.Where("product_id = ?", product.ID)
+	if movementType := c.Query("type"); movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+
+	var movements []models.StockMovement
+	if err := query.Order("id DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&movements).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch stock history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": movements, "stock": product.Stock})
+}
+
+// GetReconciliationReport compares each product's Stock with its ledger balance
+func (sc *StockController) GetReconciliationReport(c *gin.Context) {
+	vendor, ok := currentVendor(sc.DB, c)
+	if !ok {
+		return
+	}
+
+	type reportRow struct {
+		ProductID   uint   `json:"product_id"`
+		Name        string `json:"name"`
+		Stock       int    `json:"stock"`
+		LedgerStock int    `json:"ledger_stock"`
+		Difference  int    `json:"difference"`
+		Movements   int    `json:"movements"`
+	}
+
+	var rows []reportRow
+	if err := sc.DB.Table("products").
+		Select(`products.id AS product_id, products.name, products.stock,
+			COALESCE(SUM(stock_movements.quantity), 0) AS ledger_stock,
+			products.stock - COALESCE(SUM(stock_movements.quantity), 0) AS difference,
+			COUNT(stock_movements.id) AS movements`).
+		Joins("LEFT JOIN stock_movements ON stock_movements.product_id = products.id AND stock_movements.reference <> ?",
+			models.StockReferenceLedgerReset).
+		Where("products.vendor_id = ? AND products.type <> ? AND products.deleted_at IS NULL", vendor.ID, models.ProductTypeBundle).
+		Group("products.id, products.name, products.stock").
+		Order("products.id").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to build reconciliation report"})
+		return
+	}
+
+	onlyMismatched := c.DefaultQuery("only_mismatched", "true") == "true"
+	report := make([]reportRow, 0, len(rows))
+	mismatched := 0
+	for _, row := range rows {
+		if row.Difference != 0 {
+			mismatched++
+		} else if onlyMismatched {
+			continue
+		}
+		report = append(report, row)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       report,
+		"products":   len(rows),
+		"mismatched": mismatched,
+	})
+}
+
+// ReconcileStock brings a product's Stock and its ledger balance back in line
+func (sc *StockController) ReconcileStock(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	product, ok := vendorProduct(sc.DB, c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if product.Type == models.ProductTypeBundle {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "A bundle's stock follows its components"})
+		return
+	}
+
+	var request models.ReconcileStockRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	oldStock := product.Stock
+	var ledgerStock int
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		// lock the product row so no order changes stock while we reconcile
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(product, product.ID).Error; err != nil {
+			return err
+		}
+		oldStock = product.Stock
+
+		balance, err := helper.LedgerBalance(tx, product.ID)
+		if err != nil {
+			return err
+		}
+		ledgerStock = balance
+		if balance == product.Stock {
+			return nil
+		}
+
+		reason := request.Reason
+		if reason == "" {
+			reason = "stock reconciliation"
+		}
+
+		// correct Stock through the ledger, so alerts, bundles and
+		// back-in-stock subscriptions follow
+		if request.Strategy == "ledger" {
+			return helper.ApplyStockMovement(tx, &models.StockMovement{
+				ProductID: product.ID,
+				Type:      models.StockMovementAdjustment,
+				Quantity:  balance - product.Stock,
+				ActorID:   &userID,
+				Reason:    reason,
+				Reference: models.StockReferenceLedgerReset,
+			})
+		}
+
+		// book the difference without touching Stock
+		return tx.Create(&models.StockMovement{
+			ProductID:  product.ID,
+			Type:       models.StockMovementAdjustment,
+			Quantity:   product.Stock - balance,
+			StockAfter: product.Stock,
+			ActorID:    &userID,
+			Reason:     reason,
+			Reference:  "reconciliation",
+		}).Error
+	})
+	if errors.Is(err, helper.ErrInsufficientStock) {
+		c.JSON(http.StatusConflict, gin.H{"error": "The ledger balance is below zero, reconcile with the stock strategy"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reconcile stock"})
+		return
+	}
+
+	if oldStock == ledgerStock {
+		c.JSON(http.StatusOK, gin.H{"message": "Stock already matches the ledger", "stock": oldStock})
+		return
+	}
+
+	oldValJSON, _ := json.Marshal(map[string]int{"stock": oldStock, "ledger_stock": ledgerStock})
+	newValJSON, _ := json.Marshal(map[string]string{"strategy": request.Strategy})
+	sc.DB.Create(&models.AuditLog{
+		ActorID:  &userID,
+		Action:   "reconcile_stock",
+		Resource: fmt.Sprintf("product:%d", product.ID),
+		OldValue: string(oldValJSON),
+		NewValue: string(newValJSON),
+	})
+
+	helper.CheckLowStock(sc.DB, product.ID)
+
+	sc.DB.First(product, product.ID)
+	c.JSON(http.StatusOK, gin.H{"message": "Stock reconciled successfully", "stock": product.Stock})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type StockSubscriptionController struct {
+	DB *gorm.DB
+}
+
+func NewStockSubscriptionController(DB *gorm.DB) StockSubscriptionController {
+	return StockSubscriptionController{DB}
+}
+
+// Subscribe asks for a back-in-stock email for an out of stock product
+func (sc *StockSubscriptionController) Subscribe(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var product models.Product
+	if err := sc.DB.Scopes(helper.CustomerVisible).Where("products.id = ?", c.Param("id")).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found or not published"})
+		return
+	}
+	if product.Availability != models.AvailabilityOutOfStock {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Product is in stock"})
+		return
+	}
+
+	if err := subscribeBackInStock(sc.DB, userID, product.ID, models.SubscriptionSourceDirect); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "You will be notified when the product is back in stock"})
+}
+
+// Unsubscribe cancels a back-in-stock subscription
+func (sc *StockSubscriptionController) Unsubscribe(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	productID := c.Param("id")
+
+	err := sc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ? AND product_id = ?", userID, productID).
+			Delete(&models.StockSubscription{}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.WishlistItem{}).
+			Where("user_id = ? AND product_id = ?", userID, productID).
+			Update("notify_when_in_stock", false).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unsubscribe"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription removed"})
+}
+
+// GetSubscriptions lists the user's pending back-in-stock subscriptions
+func (sc *StockSubscriptionController) GetSubscriptions(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var subscriptions []models.StockSubscription
+	if err := sc.DB.Preload("Product").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&subscriptions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch subscriptions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subscriptions})
+}
+
+// subscribeBackInStock creates the subscription if the user doesn't have one yet
+func subscribeBackInStock(db *gorm.DB, userID, productID uint, source string) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.StockSubscription{
+		UserID:    userID,
+		ProductID: productID,
+		Source:    source,
+	}).Error
+}
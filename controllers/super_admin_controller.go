@@ -1,11 +1,16 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 
+	"github.com/abdullahalsazib/e-com-backend/helper"
 	"github.com/abdullahalsazib/e-com-backend/models"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SuperAdminController struct {
@@ -140,9 +145,34 @@ func (cc *CategoryController) CreateCategory(c *gin.Context) {
 		return
 	}
 
+	if payload.ParentID != nil {
+		if err := cc.DB.First(&models.Category{}, *payload.ParentID).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+			return
+		}
+	}
+
+	slug := helper.Slugify(payload.Name)
+	if slug == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Category name needs at least one letter or digit"})
+		return
+	}
+	slug, err := helper.UniqueCategorySlug(cc.DB, slug, 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create category"})
+		return
+	}
+
+	// new categories go last among their siblings
+	var sortOrder int
+	cc.DB.Model(&models.Category{}).Select("COALESCE(MAX(sort_order) + 1, 0)").
+		Where(siblingsOf(payload.ParentID)).Scan(&sortOrder)
+
 	category := models.Category{
-		Name:     payload.Name,
-		ParentID: payload.ParentID,
+		Name:      payload.Name,
+		Slug:      slug,
+		ParentID:  payload.ParentID,
+		SortOrder: sortOrder,
 	}
 	if err := cc.DB.Create(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -169,8 +199,30 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 		return
 	}
 
+	if err := helper.CheckCategoryParent(cc.DB, category.ID, payload.ParentID); err != nil {
+		if errors.Is(err, helper.ErrCategoryCycle) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+		}
+		return
+	}
+
 	category.Name = payload.Name
 	category.ParentID = payload.ParentID
+	if category.Slug == "" {
+		slug := helper.Slugify(payload.Name)
+		if slug == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Category name needs at least one letter or digit"})
+			return
+		}
+		slug, err := helper.UniqueCategorySlug(cc.DB, slug, category.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update category"})
+			return
+		}
+		category.Slug = slug
+	}
 
 	if err := cc.DB.Save(&category).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -179,23 +231,250 @@ func (cc *CategoryController) UpdateCategory(c *gin.Context) {
 	c.JSON(http.StatusOK, category)
 }
 
-// Hard delete category and related products
+// Move category under another parent (or to the root) at a sort position
+func (cc *CategoryController) MoveCategory(c *gin.Context) {
+	var category models.Category
+	if err := cc.DB.First(&category, c.Param("id")).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
+		return
+	}
+
+	var payload models.MoveCategoryRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := helper.CheckCategoryParent(cc.DB, category.ID, payload.ParentID); err != nil {
+		if errors.Is(err, helper.ErrCategoryCycle) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		} else {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Parent category not found"})
+		}
+		return
+	}
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		var siblings []models.Category
+		if err := tx.Where(siblingsOf(payload.ParentID)).Where("id <> ?", category.ID).
+			Order("sort_order, id").Find(&siblings).Error; err != nil {
+			return err
+		}
+
+		position := len(siblings)
+		if payload.Position != nil && *payload.Position < position {
+			position = *payload.Position
+		}
+		ids := make([]uint, 0, len(siblings)+1)
+		for i, sibling := range siblings {
+			if i == position {
+				ids = append(ids, category.ID)
+			}
+			ids = append(ids, sibling.ID)
+		}
+		if position == len(siblings) {
+			ids = append(ids, category.ID)
+		}
+
+		if err := tx.Model(&category).Update("parent_id", payload.ParentID).Error; err != nil {
+			return err
+		}
+		return setCategoryOrder(tx, ids)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	cc.DB.First(&category, category.ID)
+	c.JSON(http.StatusOK, category)
+}
+
+// Reorder the children of a parent (or the roots)
+func (cc *CategoryController) ReorderCategories(c *gin.Context) {
+	var payload models.ReorderCategoriesRequest
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var siblingIDs []uint
+	if err := cc.DB.Model(&models.Category{}).Where(siblingsOf(payload.ParentID)).
+		Pluck("id", &siblingIDs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// the payload must list every sibling exactly once
+	isSibling := make(map[uint]bool, len(siblingIDs))
+	for _, id := range siblingIDs {
+		isSibling[id] = true
+	}
+	for _, id := range payload.CategoryIDs {
+		if !isSibling[id] {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "category_ids must list every child of the parent exactly once"})
+			return
+		}
+		delete(isSibling, id)
+	}
+	if len(isSibling) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "category_ids must list every child of the parent exactly once"})
+		return
+	}
+
+	if err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		return setCategoryOrder(tx, payload.CategoryIDs)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Categories reordered successfully"})
+}
+
+// siblingsOf matches the children of parentID, or the roots when it is nil
+func siblingsOf(parentID *uint) clause.Expr {
+	if parentID == nil {
+		return gorm.Expr("parent_id IS NULL")
+	}
+	return gorm.Expr("parent_id = ?", *parentID)
+}
+
+// setCategoryOrder numbers the categories in the given order
+func setCategoryOrder(tx *gorm.DB, ids []uint) error {
+	for position, id := range ids {
+		if err := tx.Model(&models.Category{}).Where("id = ?", id).
+			Update("sort_order", position).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Delete category. Products and child categories that still use it are moved
+// to target_id first; without a target the deletion is refused. With
+// dry_run=true nothing changes and the planned moves are returned.
 func (cc *CategoryController) DeleteCategory(c *gin.Context) {
-	id := c.Param("id")
+	userID := c.MustGet("user_id").(uint)
+
 	var category models.Category
-	if err := cc.DB.First(&category, id).Error; err != nil {
+	if err := cc.DB.First(&category, c.Param("id")).Error; err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "Category not found"})
 		return
 	}
 
-	// Delete related products
-	cc.DB.Where("category_id = ?", id).Delete(&models.Product{})
+	// soft deleted products still reference the category
+	var products []models.Product
+	if err := cc.DB.Unscoped().Select("id", "name", "deleted_at").
+		Where("category_id = ?", category.ID).Order("id").Find(&products).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	var children []models.Category
+	if err := cc.DB.Where("parent_id = ?", category.ID).Order("sort_order, id").Find(&children).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var target *models.Category
+	if targetParam := c.Query("target_id"); targetParam != "" {
+		var found models.Category
+		if err := cc.DB.First(&found, targetParam).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target category not found"})
+			return
+		}
+		// the target must live outside of the deleted subtree
+		if err := helper.CheckCategoryParent(cc.DB, category.ID, &found.ID); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Target category cannot be the category or one of its descendants"})
+			return
+		}
+		target = &found
+	}
+
+	type movedProduct struct {
+		ID   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+	type movedCategory struct {
+		ID   uint   `json:"id"`
+		Name string `json:"name"`
+	}
+	movedProducts := make([]movedProduct, 0, len(products))
+	for _, product := range products {
+		movedProducts = append(movedProducts, movedProduct{ID: product.ID, Name: product.Name})
+	}
+	movedChildren := make([]movedCategory, 0, len(children))
+	for _, child := range children {
+		movedChildren = append(movedChildren, movedCategory{ID: child.ID, Name: child.Name})
+	}
+	plan := gin.H{
+		"category":         gin.H{"id": category.ID, "name": category.Name},
+		"products":         movedProducts,
+		"child_categories": movedChildren,
+	}
+	if target != nil {
+		plan["target"] = gin.H{"id": target.ID, "name": target.Name}
+	}
+
+	needsTarget := len(products) > 0 || len(children) > 0
+	if needsTarget && target == nil {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "Category is still in use, pass target_id to move its products and child categories",
+			"plan":  plan,
+		})
+		return
+	}
+	if c.Query("dry_run") == "true" {
+		c.JSON(http.StatusOK, gin.H{"dry_run": true, "plan": plan})
+		return
+	}
+
+	err := cc.DB.Transaction(func(tx *gorm.DB) error {
+		if target != nil {
+			if err := tx.Unscoped().Model(&models.Product{}).Where("category_id = ?", category.ID).
+				UpdateColumn("category_id", target.ID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Collection{}).Where("rule_category_id = ?", category.ID).
+				Update("rule_category_id", target.ID).Error; err != nil {
+				return err
+			}
+
+			// children keep their order, after the target's own children
+			var next int
+			if err := tx.Model(&models.Category{}).Select("COALESCE(MAX(sort_order) + 1, 0)").
+				Where("parent_id = ?", target.ID).Scan(&next).Error; err != nil {
+				return err
+			}
+			for i, child := range children {
+				if err := tx.Model(&child).Updates(map[string]interface{}{
+					"parent_id":  target.ID,
+					"sort_order": next + i,
+				}).Error; err != nil {
+					return err
+				}
+			}
+		}
+
+		// Hard delete category
+		if err := tx.Unscoped().Delete(&category).Error; err != nil {
+			return err
+		}
 
-	// Hard delete category
-	if err := cc.DB.Unscoped().Delete(&category).Error; err != nil {
+		oldValJSON, _ := json.Marshal(category)
+		newValJSON, _ := json.Marshal(plan)
+		return tx.Create(&models.AuditLog{
+			ActorID:  &userID,
+			Action:   "delete_category",
+			Resource: fmt.Sprintf("category:%d", category.ID),
+			OldValue: string(oldValJSON),
+			NewValue: string(newValJSON),
+		}).Error
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Category deleted successfully", "plan": plan})
 }
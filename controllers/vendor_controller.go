@@ -221,3 +221,30 @@ func (vc *VendorController) updateVendorStatus(c *gin.Context, newStatus string)
 
 	c.JSON(http.StatusOK, gin.H{"message": "Vendor status updated successfully"})
 }
+
+// currentVendor loads the vendor record of the logged-in user
+func currentVendor(db *gorm.DB, c *gin.Context) (*models.Vendor, bool) {
+	userID := c.MustGet("user_id").(uint)
+
+	var vendor models.Vendor
+	if err := db.Where("user_id = ?", userID).First(&vendor).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Vendor not found for this user"})
+		return nil, false
+	}
+	return &vendor, true
+}
+
+// vendorProduct loads a product that belongs to the logged-in user's vendor
+func vendorProduct(db *gorm.DB, c *gin.Context, id string) (*models.Product, bool) {
+	vendor, ok := currentVendor(db, c)
+	if !ok {
+		return nil, false
+	}
+
+	var product models.Product
+	if err := db.Where("id = ? AND vendor_id = ?", id, vendor.ID).First(&product).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "product not found or unauthorized"})
+		return nil, false
+	}
+	return &product, true
+}
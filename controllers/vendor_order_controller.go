@@ -0,0 +1,197 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type VendorOrderController struct {
+	DB *gorm.DB
+}
+
+func NewVendorOrderController(DB *gorm.DB) VendorOrderController {
+	return VendorOrderController{DB}
+}
+
+// GetVendorOrders lists the logged-in vendor's part of the orders, newest first
+func (vc *VendorOrderController) GetVendorOrders(c *gin.Context) {
+	vendor, ok := currentVendor(vc.DB, c)
+	if !ok {
+		return
+	}
+	page, limit := pagination(c)
+
+	query := vc.DB.Model(&models.VendorOrder{}).Where("vendor_id = ?", vendor.ID)
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	// from and to are dates (2006-01-02), both inclusive
+	if from := c.Query("from"); from != "" {
+		day, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "from must be a date like 2006-01-02"})
+			return
+		}
+		query = query.Where("created_at >= ?", day)
+	}
+	if to := c.Query("to"); to != "" {
+		day, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be a date like 2006-01-02"})
+			return
+		}
+		query = query.Where("created_at < ?", day.AddDate(0, 0, 1))
+	}
+
+	var total int64
+	query.Count(&total)
+
+	var vendorOrders []models.VendorOrder
+	if err := query.Preload("Items.Product").Preload("Items.Components.Product").
+		Order("created_at DESC").
+		Limit(limit).Offset((page - 1) * limit).
+		Find(&vendorOrders).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch orders"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": vendorOrders, "total": total, "page": page, "limit": limit})
+}
+
+// GetVendorOrder returns one of the logged-in vendor's orders, with only the
+// vendor's own items
+func (vc *VendorOrderController) GetVendorOrder(c *gin.Context) {
+	vendorOrder, ok := vc.vendorOrder(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": vendorOrder})
+}
+
+// vendorOrder loads a vendor order that belongs to the logged-in user's vendor
+func (vc *VendorOrderController) vendorOrder(c *gin.Context, id string) (*models.VendorOrder, bool) {
+	vendor, ok := currentVendor(vc.DB, c)
+	if !ok {
+		return nil, false
+	}
+
+	var vendorOrder models.VendorOrder
+	if err := vc.DB.Preload("Items.Product").Preload("Items.Components.Product").
+		Where("id = ? AND vendor_id = ?", id, vendor.ID).First(&vendorOrder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		} else {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch order"})
+		}
+		return nil, false
+	}
+	return &vendorOrder, true
+}
+
+// AcceptVendorOrder confirms that the vendor will fulfil a paid order; the
+// customer's order is processing from the first accepted part on
+func (vc *VendorOrderController) AcceptVendorOrder(c *gin.Context) {
+	vc.advance(c, models.OrderStatusAccepted, map[string]interface{}{"accepted_at": time.Now()},
+		models.OrderStatusPaid, models.OrderStatusProcessing)
+}
+
+// PackVendorOrder marks an accepted order as packed
+func (vc *VendorOrderController) PackVendorOrder(c *gin.Context) {
+	vc.advance(c, models.OrderStatusPacked, map[string]interface{}{"packed_at": time.Now()},
+		models.OrderStatusAccepted)
+}
+
+// ShipVendorOrder marks an order as shipped with its tracking number and
+// tells the customer
+func (vc *VendorOrderController) ShipVendorOrder(c *gin.Context) {
+	var request models.ShipVendorOrderRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	vendorOrder, ok := vc.advance(c, models.OrderStatusShipped, map[string]interface{}{
+		"shipped_at":      time.Now(),
+		"carrier":         request.Carrier,
+		"tracking_number": request.TrackingNumber,
+	}, models.OrderStatusAccepted, models.OrderStatusPacked)
+	if !ok {
+		return
+	}
+
+	var order models.Order
+	if err := vc.DB.Select("id", "user_id").First(&order, vendorOrder.OrderID).Error; err == nil {
+		message := fmt.Sprintf("Part of your order #%d is on its way.", order.ID)
+		if request.Carrier != "" {
+			message += fmt.Sprintf(" %s tracking number: %s", request.Carrier, request.TrackingNumber)
+		} else {
+			message += " Tracking number: " + request.TrackingNumber
+		}
+		helper.Notify(vc.DB, order.UserID, "order_shipped", "Your order has shipped", message,
+			fmt.Sprintf("order:%d", order.ID), true)
+	}
+}
+
+// advance moves one of the vendor's orders to status, with the given fields,
+// when it is in one of the from statuses and rolls the change up to the
+// customer's order. It writes the response.
+func (vc *VendorOrderController) advance(c *gin.Context, status models.OrderStatus,
+	fields map[string]interface{}, from ...models.OrderStatus) (*models.VendorOrder, bool) {
+	vendor, ok := currentVendor(vc.DB, c)
+	if !ok {
+		return nil, false
+	}
+
+	actorID := c.MustGet("user_id").(uint)
+	var vendorOrder models.VendorOrder
+	errWrongStatus := errors.New("wrong status")
+	err := vc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND vendor_id = ?", c.Param("id"), vendor.ID).First(&vendorOrder).Error; err != nil {
+			return err
+		}
+		allowed := false
+		for _, fromStatus := range from {
+			allowed = allowed || vendorOrder.Status == fromStatus
+		}
+		if !allowed {
+			return errWrongStatus
+		}
+		previous := vendorOrder.Status
+
+		fields["status"] = status
+		if err := tx.Model(&vendorOrder).Updates(fields).Error; err != nil {
+			return err
+		}
+		if err := helper.RecordOrderStatus(tx, vendorOrder.OrderID, &vendorOrder.ID, previous, status, &actorID,
+			fmt.Sprintf("%s marked its part %s", vendor.ShopName, status)); err != nil {
+			return err
+		}
+		return helper.SyncOrderStatus(tx, vendorOrder.OrderID, &actorID)
+	})
+	if err == gorm.ErrRecordNotFound {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Order not found"})
+		return nil, false
+	}
+	if err == errWrongStatus {
+		c.JSON(http.StatusConflict, gin.H{"error": fmt.Sprintf("A %s order can't be marked %s", vendorOrder.Status, status)})
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update order"})
+		return nil, false
+	}
+
+	vc.DB.Preload("Items.Product").Preload("Items.Components.Product").First(&vendorOrder, vendorOrder.ID)
+	c.JSON(http.StatusOK, gin.H{"data": vendorOrder})
+	return &vendorOrder, true
+}
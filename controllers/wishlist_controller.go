@@ -20,7 +20,8 @@ func NewWishlistController(DB *gorm.DB) WishlistController {
 func (ws *WishlistController) AddToWishlist(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
 	var item struct {
-		ProductID uint `json:"product_id" binding:"required"`
+		ProductID         uint `json:"product_id" binding:"required"`
+		NotifyWhenInStock bool `json:"notify_when_in_stock"` // only used for out of stock products
 	}
 
 	if err := c.ShouldBindJSON(&item); err != nil {
@@ -46,11 +47,24 @@ func (ws *WishlistController) AddToWishlist(c *gin.Context) {
 		ProductID: item.ProductID,
 	}
 
+	// offer the back-in-stock notification for out of stock products
+	var product models.Product
+	ws.DB.Select("id", "stock", "price", "type").First(&product, item.ProductID)
+	wishlistItem.NotifyWhenInStock = item.NotifyWhenInStock && product.Availability == models.AvailabilityOutOfStock
+	wishlistItem.PriceAtAdd = product.Price
+
 	if err := ws.DB.Create(&wishlistItem).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to add to wishlist"})
 		return
 	}
 
+	if wishlistItem.NotifyWhenInStock {
+		if err := subscribeBackInStock(ws.DB, userID, item.ProductID, models.SubscriptionSourceWishlist); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to subscribe to back-in-stock notification"})
+			return
+		}
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "product add successfully.",
 	})
@@ -75,9 +89,11 @@ func (ws *WishlistController) GetWishlist(c *gin.Context) {
 		}
 
 		response = append(response, map[string]interface{}{
-			"id":         item.ID,
-			"product":    product,
-			"created_at": item.CreatedAt,
+			"id":                   item.ID,
+			"product":              product,
+			"price_at_add":         item.PriceAtAdd,
+			"notify_when_in_stock": item.NotifyWhenInStock,
+			"created_at":           item.CreatedAt,
 		})
 	}
 
@@ -101,6 +117,9 @@ func (ws *WishlistController) RemoveFromWishlist(c *gin.Context) {
 		return
 	}
 
+	ws.DB.Where("user_id = ? AND product_id = ? AND source = ?", userID, item.ProductID, models.SubscriptionSourceWishlist).
+		Delete(&models.StockSubscription{})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Item removed from wishlist"})
 }
 
@@ -113,6 +132,9 @@ func (ws *WishlistController) ClearWishlist(c *gin.Context) {
 		return
 	}
 
+	ws.DB.Where("user_id = ? AND source = ?", userID, models.SubscriptionSourceWishlist).
+		Delete(&models.StockSubscription{})
+
 	c.JSON(http.StatusOK, gin.H{"message": "Wishlist cleared successfully"})
 }
 
@@ -143,7 +165,20 @@ func (ws *WishlistController) UpdateWishlistItem(c *gin.Context) {
 		return
 	}
 
-	// Update the item
+	// a back-in-stock request belongs to the old product
+	if item.NotifyWhenInStock && item.ProductID != updateData.ProductID {
+		ws.DB.Where("user_id = ? AND product_id = ? AND source = ?", userID, item.ProductID, models.SubscriptionSourceWishlist).
+			Delete(&models.StockSubscription{})
+		item.NotifyWhenInStock = false
+	}
+
+	// Update the item, the saved price now refers to the new product
+	if item.ProductID != updateData.ProductID {
+		var product models.Product
+		ws.DB.Select("id", "price").First(&product, updateData.ProductID)
+		item.PriceAtAdd = product.Price
+		item.LastNotifiedPrice = nil
+	}
 	item.ProductID = updateData.ProductID
 	if err := ws.DB.Save(&item).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update wishlist item"})
@@ -153,6 +188,56 @@ func (ws *WishlistController) UpdateWishlistItem(c *gin.Context) {
 	c.JSON(http.StatusOK, item)
 }
 
+// SetWishlistNotify turns the back-in-stock notification of a wishlist item on or off
+func (ws *WishlistController) SetWishlistNotify(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+	itemID := c.Param("id")
+
+	var request struct {
+		Enabled bool `json:"enabled"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var item models.WishlistItem
+	if err := ws.DB.Where("id = ? AND user_id = ?", itemID, userID).First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Wishlist item not found"})
+		return
+	}
+
+	if request.Enabled {
+		var product models.Product
+		if err := ws.DB.First(&product, item.ProductID).Error; err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Product does not exist"})
+			return
+		}
+		if product.Availability != models.AvailabilityOutOfStock {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Product is in stock"})
+			return
+		}
+	}
+
+	err := ws.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&item).Update("notify_when_in_stock", request.Enabled).Error; err != nil {
+			return err
+		}
+		if request.Enabled {
+			return subscribeBackInStock(tx, userID, item.ProductID, models.SubscriptionSourceWishlist)
+		}
+		// a subscription made from the product page stays
+		return tx.Where("user_id = ? AND product_id = ? AND source = ?", userID, item.ProductID, models.SubscriptionSourceWishlist).
+			Delete(&models.StockSubscription{}).Error
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update wishlist item"})
+		return
+	}
+
+	c.JSON(http.StatusOK, item)
+}
+
 // ImportWishlist imports items from localStorage to the database
 func (ws *WishlistController) ImportWishlist(c *gin.Context) {
 	userID := c.MustGet("user_id").(uint)
@@ -168,10 +253,12 @@ func (ws *WishlistController) ImportWishlist(c *gin.Context) {
 	// Validate all products exist and prepare for bulk insert
 	var validItems []models.WishlistItem
 	for _, item := range items {
-		if ws.productExists(item.ProductID) {
+		var product models.Product
+		if err := ws.DB.Select("id", "price").First(&product, item.ProductID).Error; err == nil {
 			validItems = append(validItems, models.WishlistItem{
-				UserID:    userID,
-				ProductID: item.ProductID,
+				UserID:     userID,
+				ProductID:  item.ProductID,
+				PriceAtAdd: product.Price,
 			})
 		}
 	}
@@ -201,6 +288,60 @@ func (ws *WishlistController) ImportWishlist(c *gin.Context) {
 	})
 }
 
+// GetPriceAlertPreference returns the user's price-drop alert settings
+func (ws *WishlistController) GetPriceAlertPreference(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	preference := models.PriceAlertPreference{UserID: userID, MinDropPercent: 5, EmailEnabled: true}
+	if err := ws.DB.Where("user_id = ?", userID).First(&preference).Error; err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price alert settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preference})
+}
+
+// UpdatePriceAlertPreference opts the user in or out of price-drop alerts and sets thresholds
+func (ws *WishlistController) UpdatePriceAlertPreference(c *gin.Context) {
+	userID := c.MustGet("user_id").(uint)
+
+	var request struct {
+		Enabled        bool     `json:"enabled"`
+		MinDropPercent *float64 `json:"min_drop_percent" binding:"omitempty,min=0,max=100"`
+		MinDropAmount  *float64 `json:"min_drop_amount" binding:"omitempty,min=0"`
+		EmailEnabled   *bool    `json:"email_enabled"`
+	}
+	if err := c.ShouldBindJSON(&request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	preference := models.PriceAlertPreference{UserID: userID, MinDropPercent: 5, EmailEnabled: true}
+	if err := ws.DB.Where("user_id = ?", userID).First(&preference).Error; err != nil && err != gorm.ErrRecordNotFound {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch price alert settings"})
+		return
+	}
+
+	preference.Enabled = request.Enabled
+	if request.MinDropPercent != nil {
+		preference.MinDropPercent = *request.MinDropPercent
+	}
+	if request.MinDropAmount != nil {
+		preference.MinDropAmount = *request.MinDropAmount
+	}
+	if request.EmailEnabled != nil {
+		preference.EmailEnabled = *request.EmailEnabled
+	}
+
+	// Save updates the row, or inserts it when the user has none yet
+	if err := ws.DB.Save(&preference).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save price alert settings"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": preference})
+}
+
 // productExists checks if a product with the given ID exists
 func (ws *WishlistController) productExists(productID uint) bool {
 	// Implement based on your product service
@@ -219,9 +360,11 @@ func (ws *WishlistController) getProductDetails(productID uint) (map[string]inte
 	}
 
 	return map[string]interface{}{
-		"id":    product.ID,
-		"name":  product.Name,
-		"price": product.Price,
+		"id":           product.ID,
+		"name":         product.Name,
+		"price":        product.Price,
+		"stock":        product.Stock,
+		"availability": product.Availability,
 		// Add other product fields as needed
 	}, nil
 }
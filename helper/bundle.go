@@ -0,0 +1,105 @@
+package helper
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrBundleStock is returned when a stock movement targets a bundle, whose
+// stock follows its components
+var ErrBundleStock = errors.New("bundle stock follows its components")
+
+// ErrInvalidComponents is returned for bundle components that are missing,
+// repeated, bundles themselves, digital or owned by another vendor
+var ErrInvalidComponents = errors.New("invalid bundle components")
+
+// SetBundleComponents validates and replaces the components of a bundle,
+// then syncs its stock
+func SetBundleComponents(tx *gorm.DB, bundle *models.Product, components []models.BundleComponentRequest) error {
+	if len(components) == 0 {
+		return ErrInvalidComponents
+	}
+
+	ids := make([]uint, 0, len(components))
+	seen := map[uint]bool{}
+	for _, component := range components {
+		if seen[component.ProductID] || component.ProductID == bundle.ID || component.Quantity < 1 {
+			return ErrInvalidComponents
+		}
+		seen[component.ProductID] = true
+		ids = append(ids, component.ProductID)
+	}
+
+	// digital products carry no stock for the bundle to follow
+	var count int64
+	if err := tx.Model(&models.Product{}).
+		Where("id IN ? AND vendor_id = ? AND type NOT IN ?", ids, bundle.VendorID,
+			[]string{models.ProductTypeBundle, models.ProductTypeDigital}).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(ids) {
+		return ErrInvalidComponents
+	}
+
+	if err := tx.Where("bundle_id = ?", bundle.ID).Delete(&models.BundleComponent{}).Error; err != nil {
+		return err
+	}
+	rows := make([]models.BundleComponent, 0, len(components))
+	for _, component := range components {
+		rows = append(rows, models.BundleComponent{
+			BundleID:    bundle.ID,
+			ComponentID: component.ProductID,
+			Quantity:    component.Quantity,
+		})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return err
+	}
+
+	return syncBundleStock(tx, tx.Model(&models.Product{}).Select("id").Where("id = ?", bundle.ID))
+}
+
+// SyncBundlesOf recomputes the stock of every bundle containing one of the
+// given components
+func SyncBundlesOf(tx *gorm.DB, componentIDs ...uint) error {
+	if len(componentIDs) == 0 {
+		return nil
+	}
+	return syncBundleStock(tx, tx.Model(&models.BundleComponent{}).
+		Select("bundle_id").Where("component_id IN ?", componentIDs))
+}
+
+// syncBundleStock sets the stock of the bundles selected by bundleIDs to the
+// number of complete bundles their components allow. A deleted component
+// makes the bundle unavailable. Bundles coming back in stock trigger their
+// back-in-stock subscriptions.
+func syncBundleStock(tx *gorm.DB, bundleIDs *gorm.DB) error {
+	if err := tx.Model(&models.Product{}).
+		Where("type = ? AND id IN (?)", models.ProductTypeBundle, bundleIDs).
+		UpdateColumn("stock", gorm.Expr(`(
+			SELECT COALESCE(MIN(CASE WHEN components.id IS NULL THEN 0
+				ELSE components.stock / bundle_components.quantity END), 0)
+			FROM bundle_components
+			LEFT JOIN products components ON components.id = bundle_components.component_id
+				AND components.deleted_at IS NULL
+			WHERE bundle_components.bundle_id = products.id)`)).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&models.StockSubscription{}).
+		Where("triggered_at IS NULL AND product_id IN (?)",
+			tx.Model(&models.Product{}).Select("id").
+				Where("type = ? AND stock > 0 AND id IN (?)", models.ProductTypeBundle, bundleIDs)).
+		Update("triggered_at", time.Now()).Error
+}
+
+// BundleComponents returns the components of a bundle
+func BundleComponents(db *gorm.DB, bundleID uint) ([]models.BundleComponent, error) {
+	var components []models.BundleComponent
+	err := db.Where("bundle_id = ?", bundleID).Order("id").Find(&components).Error
+	return components, err
+}
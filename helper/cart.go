@@ -0,0 +1,276 @@
+package helper
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/abdullahalsazib/e-com-backend/utils"
+	"gorm.io/gorm"
+)
+
+const (
+	CartMergeSum   = "sum"   // add the quantities up
+	CartMergeMax   = "max"   // keep the larger quantity
+	CartMergeGuest = "guest" // the guest cart's quantity wins
+	CartMergeUser  = "user"  // the user cart's quantity wins
+)
+
+// NewCartToken returns a guest cart ID and the signed token handed to the client
+func NewCartToken() (string, string, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return "", "", err
+	}
+	id := hex.EncodeToString(random)
+	return id, id + "." + utils.Sign("cart:"+id), nil
+}
+
+// ParseCartToken returns the guest cart ID of a signed token, or false when
+// the signature doesn't match
+func ParseCartToken(token string) (string, bool) {
+	id, signature, found := strings.Cut(token, ".")
+	if !found || id == "" || !utils.VerifySignature("cart:"+id, signature) {
+		return "", false
+	}
+	return id, true
+}
+
+// CartMergeStrategy is how quantities are combined when the same product is
+// in both carts (env CART_MERGE_STRATEGY: sum, max, guest or user; default sum)
+func CartMergeStrategy() string {
+	switch strategy := os.Getenv("CART_MERGE_STRATEGY"); strategy {
+	case CartMergeMax, CartMergeGuest, CartMergeUser:
+		return strategy
+	default:
+		return CartMergeSum
+	}
+}
+
+// MergeGuestCart moves the items of the guest cart behind token into the
+// user's cart and deletes the guest cart. An invalid or unknown token is ignored.
+func MergeGuestCart(db *gorm.DB, token string, userID uint) error {
+	guestID, ok := ParseCartToken(token)
+	if !ok {
+		return nil
+	}
+	strategy := CartMergeStrategy()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var guest models.Cart
+		if err := preloadItems(tx).Where("guest_token = ?", guestID).First(&guest).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		var cart models.Cart
+		if err := preloadItems(tx).Where("user_id = ?", userID).
+			FirstOrCreate(&cart, models.Cart{UserID: &userID}).Error; err != nil {
+			return err
+		}
+		// saved items share the cart, so only active items are merged into
+		// active ones; a product the user saved for later stays saved
+		active := make(map[uint]*models.CartItem, len(cart.CartItems))
+		for i := range cart.CartItems {
+			active[cart.CartItems[i].ProductID] = &cart.CartItems[i]
+		}
+		saved := make(map[uint]bool, len(cart.SavedItems))
+		for _, item := range cart.SavedItems {
+			saved[item.ProductID] = true
+		}
+
+		for _, item := range guest.CartItems {
+			current, found := active[item.ProductID]
+			if !found {
+				if err := tx.Model(&item).Update("cart_id", cart.ID).Error; err != nil {
+					return err
+				}
+				continue
+			}
+
+			quantity := current.Quantity
+			switch strategy {
+			case CartMergeSum:
+				quantity += item.Quantity
+			case CartMergeMax:
+				quantity = max(quantity, item.Quantity)
+			case CartMergeGuest:
+				quantity = item.Quantity
+			}
+			if quantity != current.Quantity {
+				if err := tx.Model(current).Update("quantity", quantity).Error; err != nil {
+					return err
+				}
+			}
+			if err := tx.Delete(&item).Error; err != nil {
+				return err
+			}
+		}
+
+		// something the guest only saved for later doesn't add to the cart
+		for _, item := range guest.SavedItems {
+			if _, found := active[item.ProductID]; found || saved[item.ProductID] {
+				if err := tx.Delete(&item).Error; err != nil {
+					return err
+				}
+				continue
+			}
+			if err := tx.Model(&item).Update("cart_id", cart.ID).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := TouchCart(tx, cart.ID); err != nil {
+			return err
+		}
+		return tx.Delete(&guest).Error
+	})
+}
+
+// preloadItems loads the items of a cart without their products, split like
+// PreloadCartItems
+func preloadItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("CartItems", "saved_for_later = ?", false).
+		Preload("SavedItems", "saved_for_later = ?", true)
+}
+
+// PreloadCartItems loads the items of a cart with their products, split into
+// the items to order and the ones saved for later
+func PreloadCartItems(db *gorm.DB) *gorm.DB {
+	return db.Preload("CartItems", "saved_for_later = ?", false).Preload("CartItems.Product").
+		Preload("SavedItems", "saved_for_later = ?", true).Preload("SavedItems.Product")
+}
+
+// ValidateCart checks the items of a cart (loaded with PreloadCartItems)
+// against the live products. Deleted or unpublished products are removed,
+// quantities are clamped to the stock and price changes since the item was
+// added are reported once. The cart is updated in place and the changes are
+// returned as warnings; out of stock items stay in the cart, flagged. Items
+// saved for later are only checked for availability.
+func ValidateCart(db *gorm.DB, cart *models.Cart) ([]models.CartWarning, error) {
+	warnings := []models.CartWarning{}
+	if len(cart.CartItems) == 0 && len(cart.SavedItems) == 0 {
+		return warnings, nil
+	}
+
+	ids := make([]uint, 0, len(cart.CartItems)+len(cart.SavedItems))
+	for _, item := range cart.CartItems {
+		ids = append(ids, item.ProductID)
+	}
+	for _, item := range cart.SavedItems {
+		ids = append(ids, item.ProductID)
+	}
+	var visibleIDs []uint
+	if err := db.Model(&models.Product{}).Scopes(CustomerVisible).
+		Where("products.id IN ?", ids).Pluck("products.id", &visibleIDs).Error; err != nil {
+		return nil, err
+	}
+	visible := make(map[uint]bool, len(visibleIDs))
+	for _, id := range visibleIDs {
+		visible[id] = true
+	}
+
+	// soft deleted products are not preloaded
+	available := func(item models.CartItem) bool {
+		return item.Product.ID != 0 && visible[item.ProductID]
+	}
+	unavailable := func(tx *gorm.DB, item models.CartItem) error {
+		warnings = append(warnings, models.CartWarning{
+			Code:      models.CartWarningUnavailable,
+			ItemID:    item.ID,
+			ProductID: item.ProductID,
+			Name:      item.Product.Name,
+			Message:   "This product is no longer available and was removed from your cart",
+		})
+		return tx.Delete(&models.CartItem{}, item.ID).Error
+	}
+
+	kept := make([]models.CartItem, 0, len(cart.CartItems))
+	saved := make([]models.CartItem, 0, len(cart.SavedItems))
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, item := range cart.SavedItems {
+			if !available(item) {
+				if err := unavailable(tx, item); err != nil {
+					return err
+				}
+				continue
+			}
+			saved = append(saved, item)
+		}
+
+		for _, item := range cart.CartItems {
+			product := item.Product
+			if !available(item) {
+				if err := unavailable(tx, item); err != nil {
+					return err
+				}
+				continue
+			}
+
+			updates := map[string]interface{}{}
+			switch {
+			case product.Type == models.ProductTypeDigital:
+				// nothing to run out of
+			case product.Stock <= 0:
+				warnings = append(warnings, models.CartWarning{
+					Code:      models.CartWarningOutOfStock,
+					ItemID:    item.ID,
+					ProductID: item.ProductID,
+					Name:      product.Name,
+					Message:   fmt.Sprintf("%s is out of stock", product.Name),
+				})
+			case item.Quantity > product.Stock:
+				oldQuantity, newQuantity := item.Quantity, product.Stock
+				item.Quantity = newQuantity
+				updates["quantity"] = newQuantity
+				warnings = append(warnings, models.CartWarning{
+					Code:        models.CartWarningQuantityReduced,
+					ItemID:      item.ID,
+					ProductID:   item.ProductID,
+					Name:        product.Name,
+					Message:     fmt.Sprintf("Only %d of %s left, the quantity was reduced", newQuantity, product.Name),
+					OldQuantity: &oldQuantity,
+					NewQuantity: &newQuantity,
+				})
+			}
+
+			if item.PriceAtAdd != product.Price {
+				// items added before prices were recorded just start tracking
+				if item.PriceAtAdd != 0 {
+					oldPrice, newPrice := item.PriceAtAdd, product.Price
+					warnings = append(warnings, models.CartWarning{
+						Code:      models.CartWarningPriceChanged,
+						ItemID:    item.ID,
+						ProductID: item.ProductID,
+						Name:      product.Name,
+						Message:   fmt.Sprintf("The price of %s changed from %.2f to %.2f", product.Name, oldPrice, newPrice),
+						OldPrice:  &oldPrice,
+						NewPrice:  &newPrice,
+					})
+				}
+				item.PriceAtAdd = product.Price
+				updates["price_at_add"] = product.Price
+			}
+
+			if len(updates) > 0 {
+				if err := tx.Model(&models.CartItem{}).Where("id = ?", item.ID).Updates(updates).Error; err != nil {
+					return err
+				}
+			}
+			kept = append(kept, item)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	cart.CartItems = kept
+	cart.SavedItems = saved
+	return warnings, nil
+}
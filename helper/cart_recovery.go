@@ -0,0 +1,114 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/abdullahalsazib/e-com-backend/utils"
+	"gorm.io/gorm"
+)
+
+// CartReminderSchedule is how long a cart has to be idle before each
+// abandoned cart reminder (env ABANDONED_CART_REMINDERS, comma separated
+// durations, default "1h,24h,72h"). An empty value turns reminders off.
+func CartReminderSchedule() []time.Duration {
+	value, set := os.LookupEnv("ABANDONED_CART_REMINDERS")
+	if !set {
+		value = "1h,24h,72h"
+	}
+
+	var schedule []time.Duration
+	for _, part := range strings.Split(value, ",") {
+		duration, err := time.ParseDuration(strings.TrimSpace(part))
+		if err != nil || duration <= 0 {
+			continue
+		}
+		// each reminder has to come after the previous one
+		if len(schedule) > 0 && duration <= schedule[len(schedule)-1] {
+			continue
+		}
+		schedule = append(schedule, duration)
+	}
+	return schedule
+}
+
+// CartAttributionWindow is how long after a reminder an order still counts as
+// recovered by it (env ABANDONED_CART_ATTRIBUTION_DAYS, default 7)
+func CartAttributionWindow() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ABANDONED_CART_ATTRIBUTION_DAYS"))
+	if err != nil || days < 1 {
+		days = 7
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// CartPurgeAge is how long a cart may be idle before it is deleted
+// (env ABANDONED_CART_PURGE_DAYS, default 90)
+func CartPurgeAge() time.Duration {
+	days, err := strconv.Atoi(os.Getenv("ABANDONED_CART_PURGE_DAYS"))
+	if err != nil || days < 1 {
+		days = 90
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+func cartRecoveryPayload(reminderID uint) string {
+	return fmt.Sprintf("cart-reminder:%d", reminderID)
+}
+
+// CartRecoveryURL is the deep link of a reminder email: the storefront cart
+// page (env CART_RECOVERY_URL) with a signed recovery token
+func CartRecoveryURL(reminderID uint) string {
+	base := os.Getenv("CART_RECOVERY_URL")
+	if base == "" {
+		base = "http://localhost:3000/cart"
+	}
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	token := fmt.Sprintf("%d.%s", reminderID, utils.Sign(cartRecoveryPayload(reminderID)))
+	return base + separator + "recovery_token=" + token
+}
+
+// parseCartRecoveryToken returns the reminder ID of a signed recovery token
+func parseCartRecoveryToken(token string) (uint, bool) {
+	idPart, signature, found := strings.Cut(token, ".")
+	if !found {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(idPart, 10, 64)
+	if err != nil || !utils.VerifySignature(cartRecoveryPayload(uint(id)), signature) {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// CartRecoveryReminder returns the reminder an order from the cart is
+// attributed to: the one behind the recovery token the checkout came with,
+// or else the last reminder of the cart within the attribution window
+func CartRecoveryReminder(db *gorm.DB, cartID uint, token string) *uint {
+	since := time.Now().Add(-CartAttributionWindow())
+	query := db.Model(&models.CartReminder{}).Where("cart_id = ? AND sent_at >= ?", cartID, since)
+	if id, ok := parseCartRecoveryToken(token); ok {
+		query = query.Where("id = ?", id)
+	}
+
+	var reminder models.CartReminder
+	if err := query.Order("sent_at DESC").First(&reminder).Error; err != nil {
+		return nil
+	}
+	return &reminder.ID
+}
+
+// TouchCart records activity on a cart, which restarts the reminder sequence
+func TouchCart(db *gorm.DB, cartID uint) error {
+	return db.Model(&models.Cart{}).Where("id = ?", cartID).Updates(map[string]interface{}{
+		"last_activity_at": time.Now(),
+		"reminders_sent":   0,
+	}).Error
+}
@@ -0,0 +1,90 @@
+package helper
+
+import (
+	"net/url"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCartReminderSchedule(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *string // nil leaves ABANDONED_CART_REMINDERS unset
+		want  []time.Duration
+	}{
+		{"default", nil, []time.Duration{time.Hour, 24 * time.Hour, 72 * time.Hour}},
+		{"custom", strPtr("30m, 2h"), []time.Duration{30 * time.Minute, 2 * time.Hour}},
+		{"empty turns reminders off", strPtr(""), nil},
+		{"invalid and non-positive skipped", strPtr("soon,0s,-1h,45m"), []time.Duration{45 * time.Minute}},
+		{"out of order skipped", strPtr("2h,1h,3h,3h"), []time.Duration{2 * time.Hour, 3 * time.Hour}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.value == nil {
+				unsetEnv(t, "ABANDONED_CART_REMINDERS")
+			} else {
+				t.Setenv("ABANDONED_CART_REMINDERS", *tt.value)
+			}
+			if got := CartReminderSchedule(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CartReminderSchedule() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCartRecoveryToken(t *testing.T) {
+	t.Setenv("SIGNING_KEY", "test-key")
+	t.Setenv("CART_RECOVERY_URL", "https://shop.example/cart?ref=email")
+
+	link, err := url.Parse(CartRecoveryURL(42))
+	if err != nil {
+		t.Fatalf("invalid recovery URL: %v", err)
+	}
+	if link.Query().Get("ref") != "email" {
+		t.Errorf("recovery URL %s lost the existing query", link)
+	}
+	token := link.Query().Get("recovery_token")
+	_, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		wantID uint
+		wantOK bool
+	}{
+		{"valid", token, 42, true},
+		{"other reminder", "43." + signature, 0, false},
+		{"tampered signature", "42." + strings.Repeat("0", len(signature)), 0, false},
+		{"no signature", "42", 0, false},
+		{"not a number", "abc." + signature, 0, false},
+		{"empty", "", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := parseCartRecoveryToken(tt.token)
+			if id != tt.wantID || ok != tt.wantOK {
+				t.Errorf("parseCartRecoveryToken(%q) = %d, %v, want %d, %v", tt.token, id, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+
+	// a token signed with another key is refused
+	t.Setenv("SIGNING_KEY", "other-key")
+	if _, ok := parseCartRecoveryToken(token); ok {
+		t.Errorf("token signed with another key was accepted")
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// unsetEnv removes an environment variable for the duration of the test
+func unsetEnv(t *testing.T, key string) {
+	t.Helper()
+	t.Setenv(key, "") // restores the old value after the test
+	os.Unsetenv(key)
+}
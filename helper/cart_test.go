@@ -0,0 +1,146 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+// TestMergeGuestCartKeepsSavedItems checks that active guest items only merge
+// into active user items, and saved items never end up in the cart.
+func TestMergeGuestCartKeepsSavedItems(t *testing.T) {
+	t.Setenv("SIGNING_KEY", "test-key")
+	t.Setenv("CART_MERGE_STRATEGY", CartMergeSum)
+	db := testDB(t)
+
+	guestID, token, err := NewCartToken()
+	if err != nil {
+		t.Fatalf("failed to create cart token: %v", err)
+	}
+	userID := uint(7)
+	guest := models.Cart{GuestToken: &guestID, CartItems: []models.CartItem{
+		{ProductID: 1, Quantity: 2},
+		{ProductID: 2, Quantity: 1, SavedForLater: true},
+		{ProductID: 3, Quantity: 1},
+		{ProductID: 4, Quantity: 1, SavedForLater: true},
+	}}
+	user := models.Cart{UserID: &userID, CartItems: []models.CartItem{
+		{ProductID: 1, Quantity: 4, SavedForLater: true},
+		{ProductID: 3, Quantity: 1},
+		{ProductID: 4, Quantity: 3},
+	}}
+	if err := db.Create(&guest).Error; err != nil {
+		t.Fatalf("failed to create guest cart: %v", err)
+	}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatalf("failed to create user cart: %v", err)
+	}
+
+	if err := MergeGuestCart(db, token, userID); err != nil {
+		t.Fatalf("merge failed: %v", err)
+	}
+
+	var cart models.Cart
+	if err := preloadItems(db).First(&cart, user.ID).Error; err != nil {
+		t.Fatalf("failed to reload user cart: %v", err)
+	}
+	quantities := func(items []models.CartItem) map[uint]int {
+		result := map[uint]int{}
+		for _, item := range items {
+			if _, found := result[item.ProductID]; found {
+				t.Errorf("product %d is in the same section twice", item.ProductID)
+			}
+			result[item.ProductID] = item.Quantity
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		got  map[uint]int
+		want map[uint]int
+	}{
+		{"active", quantities(cart.CartItems), map[uint]int{1: 2, 3: 2, 4: 3}},
+		{"saved", quantities(cart.SavedItems), map[uint]int{1: 4, 2: 1}},
+	}
+	for _, tt := range tests {
+		if len(tt.got) != len(tt.want) {
+			t.Errorf("%s items: got %v, want %v", tt.name, tt.got, tt.want)
+			continue
+		}
+		for productID, quantity := range tt.want {
+			if tt.got[productID] != quantity {
+				t.Errorf("%s items: got %v, want %v", tt.name, tt.got, tt.want)
+				break
+			}
+		}
+	}
+
+	var count int64
+	db.Model(&models.Cart{}).Where("id = ?", guest.ID).Count(&count)
+	if count != 0 {
+		t.Errorf("guest cart was not deleted")
+	}
+}
+
+func TestParseCartToken(t *testing.T) {
+	t.Setenv("SIGNING_KEY", "test-key")
+
+	id, token, err := NewCartToken()
+	if err != nil {
+		t.Fatalf("failed to create cart token: %v", err)
+	}
+	if len(id) != 32 || !strings.HasPrefix(token, id+".") {
+		t.Fatalf("unexpected cart token %q for ID %q", token, id)
+	}
+	otherID, _, _ := NewCartToken()
+	if otherID == id {
+		t.Fatalf("two cart tokens share the ID %q", id)
+	}
+	_, signature, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name   string
+		token  string
+		wantID string
+		wantOK bool
+	}{
+		{"valid", token, id, true},
+		{"other cart", otherID + "." + signature, "", false},
+		{"tampered signature", id + "." + strings.Repeat("0", len(signature)), "", false},
+		{"no signature", id, "", false},
+		{"no ID", "." + signature, "", false},
+		{"empty", "", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotID, ok := ParseCartToken(tt.token)
+			if gotID != tt.wantID || ok != tt.wantOK {
+				t.Errorf("ParseCartToken(%q) = %q, %v, want %q, %v", tt.token, gotID, ok, tt.wantID, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestCartMergeStrategy(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"sum", CartMergeSum},
+		{"max", CartMergeMax},
+		{"guest", CartMergeGuest},
+		{"user", CartMergeUser},
+		{"", CartMergeSum},
+		{"MAX", CartMergeSum},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			t.Setenv("CART_MERGE_STRATEGY", tt.value)
+			if got := CartMergeStrategy(); got != tt.want {
+				t.Errorf("CartMergeStrategy() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
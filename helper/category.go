@@ -0,0 +1,141 @@
+package helper
+
+import (
+	"errors"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// ErrCategoryCycle is returned when a category would become its own ancestor
+var ErrCategoryCycle = errors.New("category cannot be moved under itself or a descendant")
+
+// CheckCategoryParent returns ErrCategoryCycle when parentID is the category
+// itself or one of its descendants, and gorm.ErrRecordNotFound when the
+// parent doesn't exist
+func CheckCategoryParent(db *gorm.DB, categoryID uint, parentID *uint) error {
+	if parentID == nil {
+		return nil
+	}
+
+	seen := map[uint]bool{}
+	current := parentID
+	for current != nil {
+		if *current == categoryID {
+			return ErrCategoryCycle
+		}
+		if seen[*current] {
+			return ErrCategoryCycle // already broken data
+		}
+		seen[*current] = true
+
+		var parent models.Category
+		if err := db.Select("id", "parent_id").First(&parent, *current).Error; err != nil {
+			return err
+		}
+		current = parent.ParentID
+	}
+	return nil
+}
+
+// CategoryTree loads every category as nested nodes, with the number of
+// customer-visible products per node. It returns the roots and an index of
+// all nodes by ID.
+func CategoryTree(db *gorm.DB) ([]*models.CategoryNode, map[uint]*models.CategoryNode, error) {
+	var categories []models.Category
+	if err := db.Order("sort_order, id").Find(&categories).Error; err != nil {
+		return nil, nil, err
+	}
+
+	type countRow struct {
+		CategoryID uint
+		Count      int
+	}
+	var counts []countRow
+	if err := db.Model(&models.Product{}).Scopes(CustomerVisible).
+		Select("products.category_id, COUNT(*) AS count").
+		Group("products.category_id").
+		Scan(&counts).Error; err != nil {
+		return nil, nil, err
+	}
+	countByCategory := make(map[uint]int, len(counts))
+	for _, row := range counts {
+		countByCategory[row.CategoryID] = row.Count
+	}
+
+	nodes := make(map[uint]*models.CategoryNode, len(categories))
+	for _, category := range categories {
+		nodes[category.ID] = &models.CategoryNode{
+			ID:           category.ID,
+			Name:         category.Name,
+			Slug:         category.Slug,
+			Description:  category.Description,
+			ImageURL:     category.ImageURL,
+			ParentID:     category.ParentID,
+			SortOrder:    category.SortOrder,
+			ProductCount: countByCategory[category.ID],
+		}
+	}
+
+	var roots []*models.CategoryNode
+	for _, category := range categories {
+		node := nodes[category.ID]
+		if category.ParentID != nil {
+			if parent, ok := nodes[*category.ParentID]; ok {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		roots = append(roots, node)
+	}
+	for _, root := range roots {
+		sumProductCounts(root, map[uint]bool{})
+	}
+	return roots, nodes, nil
+}
+
+// sumProductCounts fills TotalProductCount bottom-up
+func sumProductCounts(node *models.CategoryNode, visiting map[uint]bool) int {
+	if visiting[node.ID] {
+		return 0
+	}
+	visiting[node.ID] = true
+	node.TotalProductCount = node.ProductCount
+	for _, child := range node.Children {
+		node.TotalProductCount += sumProductCounts(child, visiting)
+	}
+	return node.TotalProductCount
+}
+
+// TrimCategoryTree copies the tree down to the given depth (1 = only the
+// given nodes). Counts keep including deeper descendants.
+func TrimCategoryTree(nodes []*models.CategoryNode, depth int) []*models.CategoryNode {
+	trimmed := make([]*models.CategoryNode, 0, len(nodes))
+	for _, node := range nodes {
+		copied := *node
+		copied.Children = nil
+		if depth > 1 {
+			copied.Children = TrimCategoryTree(node.Children, depth-1)
+		}
+		trimmed = append(trimmed, &copied)
+	}
+	return trimmed
+}
+
+// CategoryBreadcrumbs returns the ancestors of a node, root first
+func CategoryBreadcrumbs(nodes map[uint]*models.CategoryNode, node *models.CategoryNode) []*models.CategoryNode {
+	var ancestors []*models.CategoryNode
+	seen := map[uint]bool{node.ID: true}
+	for parentID := node.ParentID; parentID != nil; {
+		parent, ok := nodes[*parentID]
+		if !ok || seen[parent.ID] {
+			break
+		}
+		seen[parent.ID] = true
+		crumb := *parent
+		crumb.Children = nil
+		ancestors = append([]*models.CategoryNode{&crumb}, ancestors...)
+		parentID = parent.ParentID
+	}
+	return ancestors
+}
@@ -0,0 +1,135 @@
+package helper
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+// categoryFixture builds the tree
+//
+//	1 Electronics (2)
+//	├── 2 Phones (5)
+//	│   └── 4 Cases (1)
+//	└── 3 Laptops (3)
+//	5 Books (4)
+func categoryFixture() ([]*models.CategoryNode, map[uint]*models.CategoryNode) {
+	id := func(v uint) *uint { return &v }
+	nodes := map[uint]*models.CategoryNode{
+		1: {ID: 1, Name: "Electronics", ProductCount: 2},
+		2: {ID: 2, Name: "Phones", ParentID: id(1), ProductCount: 5},
+		3: {ID: 3, Name: "Laptops", ParentID: id(1), ProductCount: 3},
+		4: {ID: 4, Name: "Cases", ParentID: id(2), ProductCount: 1},
+		5: {ID: 5, Name: "Books", ProductCount: 4},
+	}
+	nodes[1].Children = []*models.CategoryNode{nodes[2], nodes[3]}
+	nodes[2].Children = []*models.CategoryNode{nodes[4]}
+	roots := []*models.CategoryNode{nodes[1], nodes[5]}
+	for _, root := range roots {
+		sumProductCounts(root, map[uint]bool{})
+	}
+	return roots, nodes
+}
+
+func TestSumProductCounts(t *testing.T) {
+	_, nodes := categoryFixture()
+
+	want := map[uint]int{1: 11, 2: 6, 3: 3, 4: 1, 5: 4}
+	for id, total := range want {
+		if got := nodes[id].TotalProductCount; got != total {
+			t.Errorf("category %d total = %d, want %d", id, got, total)
+		}
+	}
+
+	// broken data: a cycle is counted once instead of looping forever
+	cycle := &models.CategoryNode{ID: 9, ProductCount: 1}
+	cycle.Children = []*models.CategoryNode{cycle}
+	if got := sumProductCounts(cycle, map[uint]bool{}); got != 1 {
+		t.Errorf("cyclic category total = %d, want 1", got)
+	}
+}
+
+func TestTrimCategoryTree(t *testing.T) {
+	roots, nodes := categoryFixture()
+
+	depthOf := func(nodes []*models.CategoryNode) int {
+		var walk func([]*models.CategoryNode) int
+		walk = func(nodes []*models.CategoryNode) int {
+			deepest := 0
+			for _, node := range nodes {
+				deepest = max(deepest, 1+walk(node.Children))
+			}
+			return deepest
+		}
+		return walk(nodes)
+	}
+
+	tests := []struct {
+		depth int
+		want  int
+	}{
+		{1, 1},
+		{2, 2},
+		{3, 3},
+		{10, 3},
+	}
+	for _, tt := range tests {
+		trimmed := TrimCategoryTree(roots, tt.depth)
+		if got := depthOf(trimmed); got != tt.want {
+			t.Errorf("TrimCategoryTree(depth %d) is %d levels deep, want %d", tt.depth, got, tt.want)
+		}
+		if trimmed[0].TotalProductCount != 11 {
+			t.Errorf("TrimCategoryTree(depth %d) changed the root total to %d", tt.depth, trimmed[0].TotalProductCount)
+		}
+	}
+
+	// trimming copies, the full tree stays intact
+	TrimCategoryTree(roots, 1)
+	if len(nodes[1].Children) != 2 || len(nodes[2].Children) != 1 {
+		t.Errorf("TrimCategoryTree modified the source tree")
+	}
+}
+
+func TestCategoryBreadcrumbs(t *testing.T) {
+	_, nodes := categoryFixture()
+
+	names := func(crumbs []*models.CategoryNode) []string {
+		result := []string{}
+		for _, crumb := range crumbs {
+			if crumb.Children != nil {
+				t.Errorf("breadcrumb %s carries its children", crumb.Name)
+			}
+			result = append(result, crumb.Name)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name string
+		id   uint
+		want []string
+	}{
+		{"root", 1, []string{}},
+		{"child", 3, []string{"Electronics"}},
+		{"grandchild", 4, []string{"Electronics", "Phones"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(CategoryBreadcrumbs(nodes, nodes[tt.id])); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("CategoryBreadcrumbs(%d) = %v, want %v", tt.id, got, tt.want)
+			}
+		})
+	}
+
+	t.Run("cycle", func(t *testing.T) {
+		id := func(v uint) *uint { return &v }
+		cyclic := map[uint]*models.CategoryNode{
+			7: {ID: 7, Name: "A", ParentID: id(8)},
+			8: {ID: 8, Name: "B", ParentID: id(7)},
+		}
+		if got := names(CategoryBreadcrumbs(cyclic, cyclic[7])); !reflect.DeepEqual(got, []string{"B"}) {
+			t.Errorf("CategoryBreadcrumbs on a cycle = %v, want [B]", got)
+		}
+	})
+}
@@ -0,0 +1,47 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// ActiveCollections limits a collection query to active collections inside
+// their schedule
+func ActiveCollections(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	return db.Where("collections.is_active = ?", true).
+		Where("(collections.starts_at IS NULL OR collections.starts_at <= ?)", now).
+		Where("(collections.ends_at IS NULL OR collections.ends_at > ?)", now)
+}
+
+// CollectionProducts builds the product query of a collection. Manual
+// collections keep their curated order, rule collections list newest first.
+func CollectionProducts(db *gorm.DB, collection *models.Collection) *gorm.DB {
+	query := db.Model(&models.Product{})
+	if collection.Type == models.CollectionManual {
+		return query.
+			Joins("JOIN collection_items ON collection_items.product_id = products.id").
+			Where("collection_items.collection_id = ?", collection.ID).
+			Order("collection_items.position, products.id")
+	}
+
+	if collection.RuleTagID != nil {
+		query = query.Where("products.id IN (?)",
+			db.Table("product_tags").Select("product_id").Where("tag_id = ?", *collection.RuleTagID))
+	}
+	if collection.RuleCategoryID != nil {
+		query = query.Where("products.category_id = ?", *collection.RuleCategoryID)
+	}
+	if collection.RuleVendorID != nil {
+		query = query.Where("products.vendor_id = ?", *collection.RuleVendorID)
+	}
+	if collection.RuleMinPrice != nil {
+		query = query.Where("products.price >= ?", *collection.RuleMinPrice)
+	}
+	if collection.RuleMaxPrice != nil {
+		query = query.Where("products.price <= ?", *collection.RuleMaxPrice)
+	}
+	return query.Order("products.created_at DESC, products.id DESC")
+}
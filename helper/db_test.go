@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// testDB opens the database the tests run against: the Postgres database in
+// TEST_DATABASE_URL when it is set (never one with real data), otherwise a
+// fresh SQLite file, so the tests always run.
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dialector := sqlite.Open(filepath.Join(t.TempDir(), "test.db") +
+		// writers wait for each other instead of failing with "database is locked"
+		"?_busy_timeout=10000&_txlock=immediate&_journal_mode=WAL")
+	if dsn := os.Getenv("TEST_DATABASE_URL"); dsn != "" {
+		dialector = postgres.Open(dsn)
+	}
+
+	db, err := gorm.Open(dialector, &gorm.Config{
+		Logger:                                   logger.Default.LogMode(logger.Silent),
+		DisableForeignKeyConstraintWhenMigrating: true,
+	})
+	if err != nil {
+		t.Fatalf("failed to connect test database: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Product{},
+		&models.StockMovement{},
+		&models.StockSubscription{},
+		&models.BundleComponent{},
+		&models.Cart{},
+		&models.CartItem{},
+		&models.WishlistItem{},
+		&models.PriceAlertPreference{},
+		&models.Notification{},
+		&models.ProductPriceHistory{},
+		&models.Category{},
+		&models.ProductSlugHistory{},
+		&models.Tag{},
+		&models.StockReservation{},
+	); err != nil {
+		t.Fatalf("failed to migrate test database: %v", err)
+	}
+	return db
+}
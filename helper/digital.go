@@ -0,0 +1,125 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/abdullahalsazib/e-com-backend/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// PrivateStorageDir is where digital product files are kept, outside of any
+// public path (env PRIVATE_STORAGE_DIR, default storage/private)
+func PrivateStorageDir() string {
+	if dir := os.Getenv("PRIVATE_STORAGE_DIR"); dir != "" {
+		return dir
+	}
+	return "storage/private"
+}
+
+// DownloadURLTTL is how long a signed download link stays valid
+// (env DOWNLOAD_URL_TTL_MINUTES, default 15)
+func DownloadURLTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("DOWNLOAD_URL_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 15
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+func downloadPayload(grantID, fileID uint, expires int64) string {
+	return fmt.Sprintf("download:%d:%d:%d", grantID, fileID, expires)
+}
+
+// SignedDownloadURL returns an expiring link to one file of a download grant
+func SignedDownloadURL(grantID, fileID uint) (string, time.Time) {
+	expiresAt := time.Now().Add(DownloadURLTTL())
+	expires := expiresAt.Unix()
+	return fmt.Sprintf("/downloads/%d/files/%d?expires=%d&signature=%s",
+		grantID, fileID, expires, utils.Sign(downloadPayload(grantID, fileID, expires))), expiresAt
+}
+
+// VerifyDownloadURL checks the signature and expiry of a download link
+func VerifyDownloadURL(grantID, fileID uint, expires int64, signature string) bool {
+	return time.Now().Unix() <= expires &&
+		utils.VerifySignature(downloadPayload(grantID, fileID, expires), signature)
+}
+
+// FulfillDigitalItems grants downloads for the digital items of a paid order
+// and assigns one license key per unit from the product's pool. It is safe to
+// call again. It returns the products whose key pool ran short.
+func FulfillDigitalItems(tx *gorm.DB, order *models.Order) ([]uint, error) {
+	var items []models.OrderItem
+	if err := tx.Joins("Product").
+		Where("order_items.order_id = ? AND \"Product\".type = ?", order.ID, models.ProductTypeDigital).
+		Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	var short []uint
+	for _, item := range items {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.DownloadGrant{
+			OrderItemID:  item.ID,
+			OrderID:      order.ID,
+			UserID:       order.UserID,
+			ProductID:    item.ProductID,
+			MaxDownloads: item.Product.DownloadLimit * item.Quantity,
+		}).Error; err != nil {
+			return nil, err
+		}
+
+		var assigned int64
+		if err := tx.Model(&models.LicenseKey{}).Where("order_item_id = ?", item.ID).Count(&assigned).Error; err != nil {
+			return nil, err
+		}
+		missing := item.Quantity - int(assigned)
+		if missing <= 0 {
+			continue
+		}
+
+		// only products that use keys have a pool
+		var pool int64
+		if err := tx.Model(&models.LicenseKey{}).Where("product_id = ?", item.ProductID).Count(&pool).Error; err != nil {
+			return nil, err
+		}
+		if pool == 0 {
+			continue
+		}
+
+		var keys []models.LicenseKey
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("product_id = ? AND order_item_id IS NULL", item.ProductID).
+			Order("id").Limit(missing).
+			Find(&keys).Error; err != nil {
+			return nil, err
+		}
+		if len(keys) > 0 {
+			ids := make([]uint, 0, len(keys))
+			for _, key := range keys {
+				ids = append(ids, key.ID)
+			}
+			if err := tx.Model(&models.LicenseKey{}).Where("id IN ?", ids).Updates(map[string]interface{}{
+				"order_item_id": item.ID,
+				"assigned_at":   time.Now(),
+			}).Error; err != nil {
+				return nil, err
+			}
+		}
+		if len(keys) < missing {
+			short = append(short, item.ProductID)
+		}
+	}
+	return short, nil
+}
+
+// RevokeDigitalItems stops the downloads of a cancelled order. Assigned
+// license keys stay with their order item, they may have been seen already.
+func RevokeDigitalItems(tx *gorm.DB, orderID uint) error {
+	return tx.Model(&models.DownloadGrant{}).
+		Where("order_id = ? AND revoked_at IS NULL", orderID).
+		Update("revoked_at", time.Now()).Error
+}
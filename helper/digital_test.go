@@ -0,0 +1,78 @@
+package helper
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/utils"
+)
+
+func TestSignedDownloadURL(t *testing.T) {
+	t.Setenv("SIGNING_KEY", "test-key")
+	t.Setenv("DOWNLOAD_URL_TTL_MINUTES", "15")
+
+	link, expiresAt := SignedDownloadURL(3, 8)
+	parsed, err := url.Parse(link)
+	if err != nil {
+		t.Fatalf("invalid download URL: %v", err)
+	}
+	if parsed.Path != "/downloads/3/files/8" {
+		t.Errorf("download URL path is %s", parsed.Path)
+	}
+	if ttl := time.Until(expiresAt); ttl < 14*time.Minute || ttl > 15*time.Minute {
+		t.Errorf("download URL expires in %s, want 15m", ttl)
+	}
+	expires, err := strconv.ParseInt(parsed.Query().Get("expires"), 10, 64)
+	if err != nil || expires != expiresAt.Unix() {
+		t.Fatalf("expires = %q, want %d", parsed.Query().Get("expires"), expiresAt.Unix())
+	}
+	signature := parsed.Query().Get("signature")
+
+	past := time.Now().Add(-time.Minute).Unix()
+	tests := []struct {
+		name      string
+		grantID   uint
+		fileID    uint
+		expires   int64
+		signature string
+		want      bool
+	}{
+		{"valid", 3, 8, expires, signature, true},
+		{"other grant", 4, 8, expires, signature, false},
+		{"other file", 3, 9, expires, signature, false},
+		{"extended expiry", 3, 8, expires + 3600, signature, false},
+		{"expired", 3, 8, past, utils.Sign(downloadPayload(3, 8, past)), false},
+		{"empty signature", 3, 8, expires, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerifyDownloadURL(tt.grantID, tt.fileID, tt.expires, tt.signature); got != tt.want {
+				t.Errorf("VerifyDownloadURL = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadURLTTL(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Duration
+	}{
+		{"30", 30 * time.Minute},
+		{"", 15 * time.Minute},
+		{"0", 15 * time.Minute},
+		{"-5", 15 * time.Minute},
+		{"soon", 15 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%q", tt.value), func(t *testing.T) {
+			t.Setenv("DOWNLOAD_URL_TTL_MINUTES", tt.value)
+			if got := DownloadURLTTL(); got != tt.want {
+				t.Errorf("DownloadURLTTL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package helper
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// CheckLowStock alerts the vendor (in-app and email) about products whose
+// stock has dropped to their reorder threshold. Each product is alerted once
+// until it is restocked above the threshold (see ApplyStockMovement).
+func CheckLowStock(db *gorm.DB, productIDs ...uint) {
+	if len(productIDs) == 0 {
+		return
+	}
+
+	var products []models.Product
+	if err := db.Preload("Vendor").
+		Where("id IN ? AND reorder_threshold > 0 AND stock <= reorder_threshold AND low_stock_alerted_at IS NULL", productIDs).
+		Find(&products).Error; err != nil {
+		log.Printf("Failed to check low stock: %v", err)
+		return
+	}
+
+	for _, product := range products {
+		// claim the alert so concurrent orders don't send it twice
+		result := db.Model(&models.Product{}).
+			Where("id = ? AND low_stock_alerted_at IS NULL", product.ID).
+			Update("low_stock_alerted_at", time.Now())
+		if result.Error != nil || result.RowsAffected == 0 {
+			continue
+		}
+
+		title := "Low stock: " + product.Name
+		message := fmt.Sprintf("%s has only %d left in stock (reorder threshold %d).",
+			product.Name, product.Stock, product.ReorderThreshold)
+		if product.Stock <= 0 {
+			title = "Out of stock: " + product.Name
+			message = fmt.Sprintf("%s is out of stock and is now hidden from customer listings.", product.Name)
+		}
+
+		if err := Notify(db, product.Vendor.UserID, "low_stock", title, message,
+			fmt.Sprintf("product:%d", product.ID), true); err != nil {
+			log.Printf("Failed to send low stock alert for product %d: %v", product.ID, err)
+		}
+	}
+}
@@ -0,0 +1,22 @@
+package helper
+
+import (
+	"os"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+// ModerationEnabled reports whether products need superadmin approval before
+// they are published (PRODUCT_MODERATION=true)
+func ModerationEnabled() bool {
+	return os.Getenv("PRODUCT_MODERATION") == "true"
+}
+
+// PublishStatus maps a vendor's requested status to the one to store: with
+// moderation on, publishing a product that isn't live yet sends it to review.
+func PublishStatus(current, requested string) string {
+	if requested == "published" && current != "published" && ModerationEnabled() {
+		return models.ProductStatusPendingReview
+	}
+	return requested
+}
@@ -0,0 +1,40 @@
+package helper
+
+import (
+	"html"
+	"log"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/abdullahalsazib/e-com-backend/utils"
+	"gorm.io/gorm"
+)
+
+// Notify stores an in-app notification for the user and, when sendEmail is
+// true, mails the same message to the user's address in the background.
+func Notify(db *gorm.DB, userID uint, kind, title, message, resource string, sendEmail bool) error {
+	notification := models.Notification{
+		UserID:   userID,
+		Type:     kind,
+		Title:    title,
+		Message:  message,
+		Resource: resource,
+	}
+	if err := db.Create(&notification).Error; err != nil {
+		return err
+	}
+
+	if sendEmail {
+		var user models.User
+		if err := db.Select("id", "email").First(&user, userID).Error; err != nil {
+			return err
+		}
+		body := "<p>" + html.EscapeString(message) + "</p>"
+		go func(email string) {
+			if err := utils.SendEmail(email, title, body); err != nil {
+				log.Printf("Failed to send %s email to user %d: %v", kind, userID, err)
+			}
+		}(user.Email)
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package helper
+
+import (
+	"errors"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidTransition = errors.New("invalid order status transition")
+
+// orderTransitions is the order state machine: the statuses each status may
+// move to. Cancelled and refunded orders are final.
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	// processing straight from pending is for orders paid on delivery
+	models.OrderStatusPending:       {models.OrderStatusPaid, models.OrderStatusPaymentFailed, models.OrderStatusProcessing, models.OrderStatusCancelled},
+	models.OrderStatusPaymentFailed: {models.OrderStatusPaid, models.OrderStatusCancelled},
+	models.OrderStatusPaid:          {models.OrderStatusProcessing, models.OrderStatusRefundPending},
+	models.OrderStatusProcessing:    {models.OrderStatusShipped, models.OrderStatusCancelled, models.OrderStatusRefundPending},
+	models.OrderStatusShipped:       {models.OrderStatusDelivered},
+	models.OrderStatusDelivered:     {models.OrderStatusRefundPending},
+	models.OrderStatusRefundPending: {models.OrderStatusRefunded},
+}
+
+// OrderTransitions returns the statuses an order in status may move to
+func OrderTransitions(status models.OrderStatus) []models.OrderStatus {
+	if transitions, ok := orderTransitions[status]; ok {
+		return transitions
+	}
+	return []models.OrderStatus{}
+}
+
+// CanTransitionOrder tells whether an order may move from one status to another
+func CanTransitionOrder(from, to models.OrderStatus) bool {
+	for _, status := range orderTransitions[from] {
+		if status == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUnpaid tells whether an order still waits for its payment and holds its
+// stock only by reservation
+func IsUnpaid(status models.OrderStatus) bool {
+	return status == models.OrderStatusPending || status == models.OrderStatusPaymentFailed
+}
+
+// SetOrderStatus moves an order to status and records the change. It returns
+// ErrInvalidTransition when the state machine doesn't allow it. The caller
+// should hold a lock on the order.
+func SetOrderStatus(tx *gorm.DB, order *models.Order, status models.OrderStatus, actorID *uint, note string) error {
+	if !CanTransitionOrder(order.Status, status) {
+		return ErrInvalidTransition
+	}
+	from := order.Status
+	if err := tx.Model(order).Update("status", status).Error; err != nil {
+		return err
+	}
+	order.Status = status
+	return RecordOrderStatus(tx, order.ID, nil, from, status, actorID, note)
+}
+
+// RecordOrderStatus adds an entry to the status history of an order, or of
+// one of its vendor orders
+func RecordOrderStatus(tx *gorm.DB, orderID uint, vendorOrderID *uint, from, to models.OrderStatus, actorID *uint, note string) error {
+	return tx.Create(&models.OrderStatusHistory{
+		OrderID:       orderID,
+		VendorOrderID: vendorOrderID,
+		FromStatus:    from,
+		ToStatus:      to,
+		ActorID:       actorID,
+		Note:          note,
+	}).Error
+}
@@ -0,0 +1,67 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+func TestCanTransitionOrder(t *testing.T) {
+	tests := []struct {
+		from, to models.OrderStatus
+		want     bool
+	}{
+		{models.OrderStatusPending, models.OrderStatusPaid, true},
+		{models.OrderStatusPending, models.OrderStatusPaymentFailed, true},
+		{models.OrderStatusPending, models.OrderStatusProcessing, true}, // paid on delivery
+		{models.OrderStatusPending, models.OrderStatusCancelled, true},
+		{models.OrderStatusPending, models.OrderStatusShipped, false},
+		{models.OrderStatusPaymentFailed, models.OrderStatusPaid, true},
+		{models.OrderStatusPaymentFailed, models.OrderStatusProcessing, false},
+		{models.OrderStatusPaid, models.OrderStatusProcessing, true},
+		{models.OrderStatusPaid, models.OrderStatusCancelled, false},
+		{models.OrderStatusPaid, models.OrderStatusRefundPending, true},
+		{models.OrderStatusProcessing, models.OrderStatusShipped, true},
+		{models.OrderStatusProcessing, models.OrderStatusDelivered, false},
+		{models.OrderStatusShipped, models.OrderStatusDelivered, true},
+		{models.OrderStatusShipped, models.OrderStatusCancelled, false},
+		{models.OrderStatusDelivered, models.OrderStatusRefundPending, true},
+		{models.OrderStatusRefundPending, models.OrderStatusRefunded, true},
+		{models.OrderStatusRefunded, models.OrderStatusRefundPending, false},
+		{models.OrderStatusCancelled, models.OrderStatusPending, false},
+		{models.OrderStatusPending, models.OrderStatusPending, false},
+		{"unknown", models.OrderStatusPaid, false},
+	}
+	for _, tt := range tests {
+		if got := CanTransitionOrder(tt.from, tt.to); got != tt.want {
+			t.Errorf("CanTransitionOrder(%s, %s) = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestOrderTransitionsFinalStatuses(t *testing.T) {
+	for _, status := range []models.OrderStatus{models.OrderStatusCancelled, models.OrderStatusRefunded, "unknown"} {
+		transitions := OrderTransitions(status)
+		if transitions == nil || len(transitions) != 0 {
+			t.Errorf("OrderTransitions(%s) = %v, want an empty list", status, transitions)
+		}
+	}
+}
+
+func TestIsUnpaid(t *testing.T) {
+	tests := []struct {
+		status models.OrderStatus
+		want   bool
+	}{
+		{models.OrderStatusPending, true},
+		{models.OrderStatusPaymentFailed, true},
+		{models.OrderStatusPaid, false},
+		{models.OrderStatusProcessing, false},
+		{models.OrderStatusCancelled, false},
+	}
+	for _, tt := range tests {
+		if got := IsUnpaid(tt.status); got != tt.want {
+			t.Errorf("IsUnpaid(%s) = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
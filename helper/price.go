@@ -0,0 +1,60 @@
+package helper
+
+import (
+	"log"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChangePrice sets a product's price and records the change in the price
+// history. Nothing is recorded when the price stays the same.
+func ChangePrice(tx *gorm.DB, productID uint, newPrice float64, actorID *uint, source string, scheduleID *uint) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "price").First(&product, productID).Error; err != nil {
+		return err
+	}
+	if product.Price == newPrice {
+		return nil
+	}
+
+	if err := tx.Model(&product).Update("price", newPrice).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ProductPriceHistory{
+		ProductID:  productID,
+		OldPrice:   product.Price,
+		NewPrice:   newPrice,
+		ActorID:    actorID,
+		Source:     source,
+		ScheduleID: scheduleID,
+	}).Error
+}
+
+// RestoreSchedulePrice ends the sale of an active price schedule: the price
+// goes back to the original one and the compare-at price is cleared. A price
+// the vendor changed by hand during the sale is kept; restored tells which
+// happened.
+func RestoreSchedulePrice(tx *gorm.DB, schedule *models.PriceSchedule, actorID *uint) (bool, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id", "price").First(&product, schedule.ProductID).Error; err != nil {
+		return false, err
+	}
+	if err := tx.Model(&product).Update("compare_at_price", nil).Error; err != nil {
+		return false, err
+	}
+	if schedule.OriginalPrice == nil {
+		return false, nil
+	}
+	if product.Price != schedule.Price {
+		log.Printf("Price schedule %d ended without restoring %.2f: product %d was repriced to %.2f meanwhile",
+			schedule.ID, *schedule.OriginalPrice, product.ID, product.Price)
+		return false, nil
+	}
+	return true, ChangePrice(tx, product.ID, *schedule.OriginalPrice, actorID,
+		models.PriceSourceScheduleEnd, &schedule.ID)
+}
@@ -0,0 +1,100 @@
+package helper
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+type priceDrop struct {
+	ItemID            uint
+	UserID            uint
+	ProductID         uint
+	Name              string
+	LastNotifiedPrice *float64
+	ReferencePrice    float64
+	Price             float64
+	MinDropPercent    float64
+	MinDropAmount     float64
+	EmailEnabled      bool
+}
+
+// NotifyPriceDrops sends one alert per user listing the wishlist items whose
+// price dropped below the price at add time (or the last alerted price).
+// When productIDs are given only those products are checked. Each drop is
+// claimed before it is alerted, so runs that overlap never alert it twice.
+func NotifyPriceDrops(db *gorm.DB, productIDs ...uint) error {
+	query := db.Table("wishlist_items").
+		Select(`wishlist_items.id AS item_id, wishlist_items.user_id, products.id AS product_id, products.name,
+			wishlist_items.last_notified_price,
+			COALESCE(wishlist_items.last_notified_price, wishlist_items.price_at_add) AS reference_price,
+			products.price, price_alert_preferences.min_drop_percent,
+			price_alert_preferences.min_drop_amount, price_alert_preferences.email_enabled`).
+		Joins("JOIN products ON products.id = wishlist_items.product_id AND products.deleted_at IS NULL").
+		Joins("JOIN price_alert_preferences ON price_alert_preferences.user_id = wishlist_items.user_id").
+		Where("price_alert_preferences.enabled = ? AND wishlist_items.price_at_add > 0", true).
+		Scopes(CustomerVisible).
+		Where("products.price < COALESCE(wishlist_items.last_notified_price, wishlist_items.price_at_add)")
+	if len(productIDs) > 0 {
+		query = query.Where("products.id IN ?", productIDs)
+	}
+
+	var drops []priceDrop
+	if err := query.Scan(&drops).Error; err != nil {
+		return err
+	}
+
+	byUser := make(map[uint][]priceDrop)
+	for _, drop := range drops {
+		amount := drop.ReferencePrice - drop.Price
+		percent := amount / drop.ReferencePrice * 100
+		if amount < drop.MinDropAmount || percent < drop.MinDropPercent {
+			continue
+		}
+		claimed, err := claimPriceDrop(db, drop)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			byUser[drop.UserID] = append(byUser[drop.UserID], drop)
+		}
+	}
+
+	for userID, userDrops := range byUser {
+		lines := make([]string, 0, len(userDrops))
+		for _, drop := range userDrops {
+			lines = append(lines, fmt.Sprintf("%s: %.2f → %.2f (-%.0f%%)", drop.Name, drop.ReferencePrice, drop.Price,
+				(drop.ReferencePrice-drop.Price)/drop.ReferencePrice*100))
+		}
+
+		title := "Price drop on your wishlist"
+		message := "Items on your wishlist got cheaper: " + strings.Join(lines, "; ")
+		if err := Notify(db, userID, "price_drop", title, message,
+			fmt.Sprintf("product:%d", userDrops[0].ProductID), userDrops[0].EmailEnabled); err != nil {
+			log.Printf("Failed to send price drop alert to user %d: %v", userID, err)
+			// give the drops back so the next run retries them
+			for _, drop := range userDrops {
+				db.Model(&models.WishlistItem{}).Where("id = ? AND last_notified_price = ?", drop.ItemID, drop.Price).
+					Update("last_notified_price", drop.LastNotifiedPrice)
+			}
+		}
+	}
+
+	return nil
+}
+
+// claimPriceDrop records the drop as alerted, unless another run changed the
+// last alerted price since the drop was read
+func claimPriceDrop(db *gorm.DB, drop priceDrop) (bool, error) {
+	query := db.Model(&models.WishlistItem{}).Where("id = ?", drop.ItemID)
+	if drop.LastNotifiedPrice == nil {
+		query = query.Where("last_notified_price IS NULL")
+	} else {
+		query = query.Where("last_notified_price = ?", *drop.LastNotifiedPrice)
+	}
+	result := query.Update("last_notified_price", drop.Price)
+	return result.RowsAffected == 1, result.Error
+}
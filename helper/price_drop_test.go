@@ -0,0 +1,53 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+// TestNotifyPriceDropsOnce checks a drop is alerted once: a later run finds
+// nothing new, and a run holding the drop read before the alert loses the claim.
+func TestNotifyPriceDropsOnce(t *testing.T) {
+	db := testDB(t)
+
+	product := models.Product{UserID: 1, VendorID: 1, CategoryID: 1, Name: "price drop test", Price: 80, Status: "published"}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	userID := uint(9)
+	if err := db.Create(&models.PriceAlertPreference{UserID: userID, Enabled: true}).Error; err != nil {
+		t.Fatalf("failed to create alert preference: %v", err)
+	}
+	item := models.WishlistItem{UserID: userID, ProductID: product.ID, PriceAtAdd: 100}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("failed to create wishlist item: %v", err)
+	}
+	// what an overlapping run read before the first alert went out
+	stale := priceDrop{ItemID: item.ID, UserID: userID, ProductID: product.ID, ReferencePrice: 100, Price: 80}
+
+	for run := 1; run <= 2; run++ {
+		if err := NotifyPriceDrops(db, product.ID); err != nil {
+			t.Fatalf("run %d failed: %v", run, err)
+		}
+	}
+	claimed, err := claimPriceDrop(db, stale)
+	if err != nil {
+		t.Fatalf("claim failed: %v", err)
+	}
+	if claimed {
+		t.Errorf("a drop that was already alerted was claimed again")
+	}
+
+	var alerts int64
+	db.Model(&models.Notification{}).Where("user_id = ? AND type = ?", userID, "price_drop").Count(&alerts)
+	if alerts != 1 {
+		t.Errorf("%d price drop alerts were sent, want 1", alerts)
+	}
+	if err := db.First(&item, item.ID).Error; err != nil {
+		t.Fatalf("failed to reload wishlist item: %v", err)
+	}
+	if item.LastNotifiedPrice == nil || *item.LastNotifiedPrice != 80 {
+		t.Errorf("last notified price is %v, want 80", item.LastNotifiedPrice)
+	}
+}
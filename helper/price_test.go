@@ -0,0 +1,50 @@
+package helper
+
+import (
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+func TestRestoreSchedulePrice(t *testing.T) {
+	db := testDB(t)
+
+	original := 100.0
+	tests := []struct {
+		name         string
+		currentPrice float64 // product price when the sale ends
+		wantRestored bool
+		wantPrice    float64
+	}{
+		{"sale price untouched", 70, true, 100},
+		{"repriced by hand during the sale", 85, false, 85},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			compareAt := original
+			product := models.Product{UserID: 1, VendorID: 1, CategoryID: 1, Name: tt.name,
+				Price: tt.currentPrice, CompareAtPrice: &compareAt}
+			if err := db.Create(&product).Error; err != nil {
+				t.Fatalf("failed to create product: %v", err)
+			}
+			schedule := models.PriceSchedule{ProductID: product.ID, Price: 70, OriginalPrice: &original}
+
+			restored, err := RestoreSchedulePrice(db, &schedule, nil)
+			if err != nil {
+				t.Fatalf("restore failed: %v", err)
+			}
+			if restored != tt.wantRestored {
+				t.Errorf("restored = %v, want %v", restored, tt.wantRestored)
+			}
+			if err := db.First(&product, product.ID).Error; err != nil {
+				t.Fatalf("failed to reload product: %v", err)
+			}
+			if product.Price != tt.wantPrice {
+				t.Errorf("price is %.2f, want %.2f", product.Price, tt.wantPrice)
+			}
+			if product.CompareAtPrice != nil {
+				t.Errorf("compare-at price %.2f was not cleared", *product.CompareAtPrice)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package helper
+
+import (
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// CustomerVisible limits a product query to what customers may see: published
+// products and drafts whose publish_at has passed, minus products whose
+// unpublish_at has passed. It follows the schedule even when the publish
+// scheduler is lagging behind. With moderation on, a due draft still has to
+// be approved first, so only published products are shown.
+func CustomerVisible(db *gorm.DB) *gorm.DB {
+	now := time.Now()
+	if ModerationEnabled() {
+		db = db.Where("products.status = ?", "published")
+	} else {
+		db = db.Where("(products.status = ? OR (products.status = ? AND products.publish_at <= ?))", "published", "draft", now)
+	}
+	return db.Where("(products.unpublish_at IS NULL OR products.unpublish_at > ?)", now)
+}
+
+// InStock limits a product query to products that can be bought now. Digital
+// products have no stock to run out of.
+func InStock(db *gorm.DB) *gorm.DB {
+	return db.Where("(products.stock > 0 OR products.type = ?)", models.ProductTypeDigital)
+}
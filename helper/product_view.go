@@ -0,0 +1,136 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// a repeated view by the same viewer within this window is not counted again
+const viewCountWindow = 30 * time.Minute
+
+// RecentlyViewedLimit is how many products a viewer's history keeps
+// (env RECENTLY_VIEWED_LIMIT, default 50)
+func RecentlyViewedLimit() int {
+	if limit, err := strconv.Atoi(os.Getenv("RECENTLY_VIEWED_LIMIT")); err == nil && limit > 0 {
+		return limit
+	}
+	return 50
+}
+
+func UserViewer(userID uint) string {
+	return fmt.Sprintf("user:%d", userID)
+}
+
+func ClientViewer(clientID string) string {
+	return "client:" + clientID
+}
+
+// RecordProductView stores the view in the viewer's history and counts it
+// for the product unless the viewer saw it moments ago
+func RecordProductView(db *gorm.DB, productID uint, userID *uint, clientID string) error {
+	var viewer string
+	switch {
+	case userID != nil:
+		viewer = UserViewer(*userID)
+	case clientID != "":
+		viewer = ClientViewer(clientID)
+	default:
+		return nil
+	}
+
+	now := time.Now()
+	var previous models.ProductView
+	err := db.Where("viewer = ? AND product_id = ?", viewer, productID).First(&previous).Error
+	counted := err != nil || now.Sub(previous.ViewedAt) > viewCountWindow
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "viewer"}, {Name: "product_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"viewed_at"}),
+		}).Create(&models.ProductView{
+			Viewer:    viewer,
+			ProductID: productID,
+			UserID:    userID,
+			ViewedAt:  now,
+		}).Error; err != nil {
+			return err
+		}
+		if err := trimViewHistory(tx, viewer); err != nil {
+			return err
+		}
+		if !counted {
+			return nil
+		}
+
+		if err := tx.Model(&models.Product{}).Where("id = ?", productID).
+			UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "product_id"}, {Name: "day"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{"views": gorm.Expr("product_view_stats.views + 1")}),
+		}).Create(&models.ProductViewStat{
+			ProductID: productID,
+			Day:       now.Truncate(24 * time.Hour),
+			Views:     1,
+		}).Error
+	})
+}
+
+// MergeViewHistory moves an anonymous visitor's history into the user's
+// account, keeping the latest view of each product
+func MergeViewHistory(db *gorm.DB, clientID string, userID uint) error {
+	if clientID == "" {
+		return nil
+	}
+	client := ClientViewer(clientID)
+	viewer := UserViewer(userID)
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		var views []models.ProductView
+		if err := tx.Where("viewer = ?", client).Find(&views).Error; err != nil {
+			return err
+		}
+		if len(views) == 0 {
+			return nil
+		}
+
+		merged := make([]models.ProductView, 0, len(views))
+		for _, view := range views {
+			merged = append(merged, models.ProductView{
+				Viewer:    viewer,
+				ProductID: view.ProductID,
+				UserID:    &userID,
+				ViewedAt:  view.ViewedAt,
+			})
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "viewer"}, {Name: "product_id"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"viewed_at": gorm.Expr("GREATEST(product_views.viewed_at, excluded.viewed_at)"),
+			}),
+		}).Create(&merged).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("viewer = ?", client).Delete(&models.ProductView{}).Error; err != nil {
+			return err
+		}
+		return trimViewHistory(tx, viewer)
+	})
+}
+
+// trimViewHistory drops everything but the viewer's latest views
+func trimViewHistory(tx *gorm.DB, viewer string) error {
+	return tx.Where("viewer = ? AND id NOT IN (?)", viewer,
+		tx.Model(&models.ProductView{}).Select("id").
+			Where("viewer = ?", viewer).
+			Order("viewed_at DESC").
+			Limit(RecentlyViewedLimit())).
+		Delete(&models.ProductView{}).Error
+}
@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// ReservationTTL is how long a pending order holds its stock (RESERVATION_TTL_MINUTES, default 30)
+func ReservationTTL() time.Duration {
+	minutes, err := strconv.Atoi(os.Getenv("RESERVATION_TTL_MINUTES"))
+	if err != nil || minutes <= 0 {
+		minutes = 30
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// PaysOnDelivery tells whether an order with this payment method is paid
+// when it is delivered (env PAY_ON_DELIVERY_METHODS, comma separated, default
+// "cod,cash_on_delivery"). Such orders don't wait for an online payment, so
+// their stock is sold right away instead of held until a reservation expires.
+func PaysOnDelivery(paymentMethod string) bool {
+	methods, set := os.LookupEnv("PAY_ON_DELIVERY_METHODS")
+	if !set {
+		methods = "cod,cash_on_delivery"
+	}
+	for _, method := range strings.Split(methods, ",") {
+		if method = strings.TrimSpace(method); method != "" && strings.EqualFold(method, paymentMethod) {
+			return true
+		}
+	}
+	return false
+}
+
+// CommitReservations marks the active reservations of an order as sold
+func CommitReservations(tx *gorm.DB, orderID uint) error {
+	return tx.Model(&models.StockReservation{}).
+		Where("order_id = ? AND status = ?", orderID, models.ReservationActive).
+		Update("status", models.ReservationCommitted).Error
+}
+
+// ReleaseOrderStock returns the stock held by an order. Orders placed before
+// reservations existed have none, so their items are restocked directly.
+func ReleaseOrderStock(tx *gorm.DB, order *models.Order, actorID *uint, reason string) error {
+	return returnOrderStock(tx, order, models.StockMovementCancellation, actorID, reason)
+}
+
+// RestockRefundedOrder puts the goods of a refunded order back in stock as
+// return movements, the same way ReleaseOrderStock does for a cancellation
+func RestockRefundedOrder(tx *gorm.DB, order *models.Order, actorID *uint, reason string) error {
+	return returnOrderStock(tx, order, models.StockMovementReturn, actorID, reason)
+}
+
+// returnOrderStock books the stock an order took back with movements of the
+// given type and marks its reservations released, so it is returned once
+func returnOrderStock(tx *gorm.DB, order *models.Order, movementType models.StockMovementType, actorID *uint, reason string) error {
+	reference := fmt.Sprintf("order:%d", order.ID)
+
+	var reservations []models.StockReservation
+	if err := tx.Where("order_id = ?", order.ID).Find(&reservations).Error; err != nil {
+		return err
+	}
+
+	if len(reservations) == 0 {
+		// digital products have no stock to put back
+		var items []models.OrderItem
+		if err := tx.Joins("Product").
+			Where("order_items.order_id = ? AND COALESCE(\"Product\".type, '') <> ?", order.ID, models.ProductTypeDigital).
+			Find(&items).Error; err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := ApplyStockMovement(tx, &models.StockMovement{
+				ProductID: item.ProductID,
+				Type:      movementType,
+				Quantity:  item.Quantity,
+				ActorID:   actorID,
+				Reason:    reason,
+				Reference: reference,
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for _, reservation := range reservations {
+		if reservation.Status == models.ReservationReleased {
+			continue
+		}
+		if err := ApplyStockMovement(tx, &models.StockMovement{
+			ProductID: reservation.ProductID,
+			Type:      movementType,
+			Quantity:  reservation.Quantity,
+			ActorID:   actorID,
+			Reason:    reason,
+			Reference: reference,
+		}); err != nil {
+			return err
+		}
+		if err := tx.Model(&reservation).Update("status", models.ReservationReleased).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package helper
+
+import (
+	"testing"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+// TestRestockRefundedOrder checks a refund books the sold stock back as
+// returns exactly once
+func TestRestockRefundedOrder(t *testing.T) {
+	db := testDB(t)
+
+	product := models.Product{UserID: 1, VendorID: 1, CategoryID: 1, Name: "refund test", Stock: 4}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	order := models.Order{}
+	order.ID = 77
+	if err := db.Create(&models.StockReservation{ProductID: product.ID, OrderID: order.ID, Quantity: 3,
+		Status: models.ReservationCommitted, ExpiresAt: time.Now()}).Error; err != nil {
+		t.Fatalf("failed to create reservation: %v", err)
+	}
+
+	for run := 1; run <= 2; run++ {
+		if err := RestockRefundedOrder(db, &order, nil, "order refunded"); err != nil {
+			t.Fatalf("restock %d failed: %v", run, err)
+		}
+	}
+
+	if err := db.First(&product, product.ID).Error; err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	if product.Stock != 7 {
+		t.Errorf("stock is %d, want 7", product.Stock)
+	}
+	var movements []models.StockMovement
+	db.Where("product_id = ?", product.ID).Find(&movements)
+	if len(movements) != 1 || movements[0].Type != models.StockMovementReturn || movements[0].Quantity != 3 {
+		t.Errorf("ledger = %+v, want one return of 3", movements)
+	}
+	var reservation models.StockReservation
+	db.Where("order_id = ?", order.ID).First(&reservation)
+	if reservation.Status != models.ReservationReleased {
+		t.Errorf("reservation is %s, want %s", reservation.Status, models.ReservationReleased)
+	}
+}
@@ -0,0 +1,94 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// ProductSnapshot holds the product fields covered by revisions. Stock is
+// left out on purpose: it is tracked by the stock ledger.
+type ProductSnapshot struct {
+	Name             string  `json:"name"`
+	Description      string  `json:"description"`
+	Price            float64 `json:"price"`
+	ImageURL         string  `json:"image_url"`
+	CategoryID       uint    `json:"category_id"`
+	Status           string  `json:"status"`
+	ReorderThreshold int     `json:"reorder_threshold"`
+	MetaTitle        string  `json:"meta_title"`
+	MetaDescription  string  `json:"meta_description"`
+}
+
+// FieldChange is one changed field between two snapshots
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+func SnapshotOf(product *models.Product) ProductSnapshot {
+	return ProductSnapshot{
+		Name:             product.Name,
+		Description:      product.Description,
+		Price:            product.Price,
+		ImageURL:         product.ImageURL,
+		CategoryID:       product.CategoryID,
+		Status:           product.Status,
+		ReorderThreshold: product.ReorderThreshold,
+		MetaTitle:        product.MetaTitle,
+		MetaDescription:  product.MetaDescription,
+	}
+}
+
+// SaveRevision stores the given snapshot as the next revision of the product
+func SaveRevision(tx *gorm.DB, productID uint, snapshot ProductSnapshot, actorID *uint, action string) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+
+	var version int
+	if err := tx.Model(&models.ProductRevision{}).
+		Select("COALESCE(MAX(version), 0)").
+		Where("product_id = ?", productID).
+		Scan(&version).Error; err != nil {
+		return err
+	}
+
+	return tx.Create(&models.ProductRevision{
+		ProductID: productID,
+		Version:   version + 1,
+		Snapshot:  string(data),
+		Action:    action,
+		ActorID:   actorID,
+	}).Error
+}
+
+// ParseSnapshot decodes the snapshot stored in a revision
+func ParseSnapshot(revision *models.ProductRevision) (ProductSnapshot, error) {
+	var snapshot ProductSnapshot
+	err := json.Unmarshal([]byte(revision.Snapshot), &snapshot)
+	return snapshot, err
+}
+
+// DiffSnapshots lists the fields that differ from one snapshot to the other
+func DiffSnapshots(from, to ProductSnapshot) []FieldChange {
+	changes := []FieldChange{}
+	fromValue := reflect.ValueOf(from)
+	toValue := reflect.ValueOf(to)
+	for i := 0; i < fromValue.NumField(); i++ {
+		a := fromValue.Field(i).Interface()
+		b := toValue.Field(i).Interface()
+		if a != b {
+			changes = append(changes, FieldChange{
+				Field: fromValue.Type().Field(i).Tag.Get("json"),
+				From:  a,
+				To:    b,
+			})
+		}
+	}
+	return changes
+}
@@ -0,0 +1,80 @@
+package helper
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+func TestDiffSnapshots(t *testing.T) {
+	base := ProductSnapshot{
+		Name:             "Desk Lamp",
+		Description:      "Warm light",
+		Price:            25,
+		ImageURL:         "lamp.jpg",
+		CategoryID:       3,
+		Status:           "published",
+		ReorderThreshold: 5,
+	}
+
+	tests := []struct {
+		name   string
+		change func(*ProductSnapshot)
+		want   []FieldChange
+	}{
+		{"no change", func(*ProductSnapshot) {}, []FieldChange{}},
+		{"price", func(s *ProductSnapshot) { s.Price = 19.5 },
+			[]FieldChange{{Field: "price", From: 25.0, To: 19.5}}},
+		{"several fields in struct order", func(s *ProductSnapshot) {
+			s.MetaTitle = "Lamp"
+			s.Name = "Desk Lamp XL"
+			s.CategoryID = 4
+		}, []FieldChange{
+			{Field: "name", From: "Desk Lamp", To: "Desk Lamp XL"},
+			{Field: "category_id", From: uint(3), To: uint(4)},
+			{Field: "meta_title", From: "", To: "Lamp"},
+		}},
+		{"cleared text", func(s *ProductSnapshot) { s.Description = "" },
+			[]FieldChange{{Field: "description", From: "Warm light", To: ""}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			tt.change(&changed)
+			if got := DiffSnapshots(base, changed); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffSnapshots = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSnapshotRoundTrip checks a stored snapshot reads back unchanged, and
+// that stock is not part of it
+func TestSnapshotRoundTrip(t *testing.T) {
+	product := models.Product{Name: "Desk Lamp", Price: 25, Stock: 9, CategoryID: 3, Status: "draft",
+		ReorderThreshold: 2, MetaDescription: "A lamp"}
+	snapshot := SnapshotOf(&product)
+
+	product.Stock = 1
+	if changes := DiffSnapshots(snapshot, SnapshotOf(&product)); len(changes) != 0 {
+		t.Errorf("a stock change shows up in the snapshot diff: %+v", changes)
+	}
+
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatalf("failed to encode snapshot: %v", err)
+	}
+	parsed, err := ParseSnapshot(&models.ProductRevision{Snapshot: string(data)})
+	if err != nil {
+		t.Fatalf("failed to parse snapshot: %v", err)
+	}
+	if parsed != snapshot {
+		t.Errorf("parsed snapshot %+v, want %+v", parsed, snapshot)
+	}
+
+	if _, err := ParseSnapshot(&models.ProductRevision{Snapshot: "{"}); err == nil {
+		t.Errorf("a corrupt snapshot parsed without error")
+	}
+}
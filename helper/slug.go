@@ -0,0 +1,124 @@
+package helper
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Slugify turns a name into a lowercase, dash separated URL segment
+func Slugify(name string) string {
+	slug := nonSlugChars.ReplaceAllString(strings.ToLower(name), "-")
+	slug = strings.Trim(slug, "-")
+	if len(slug) > 200 {
+		slug = strings.TrimRight(slug[:200], "-")
+	}
+	return slug
+}
+
+// uniqueProductSlug returns the slug itself or the first free "slug-N". A slug
+// is taken when another live product uses it or had it before a rename.
+// Purely numeric slugs are prefixed so they can't be mistaken for an ID.
+func uniqueProductSlug(tx *gorm.DB, slug string, productID uint) (string, error) {
+	if slug == "" {
+		slug = "product"
+	}
+	if _, err := strconv.Atoi(slug); err == nil {
+		slug = "product-" + slug
+	}
+
+	candidate := slug
+	for i := 2; ; i++ {
+		var count int64
+		if err := tx.Model(&models.Product{}).
+			Where("slug = ? AND id <> ?", candidate, productID).
+			Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			if err := tx.Model(&models.ProductSlugHistory{}).
+				Where("slug = ? AND product_id <> ?", candidate, productID).
+				Count(&count).Error; err != nil {
+				return "", err
+			}
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+}
+
+// UniqueCategorySlug returns the slug itself or the first free "slug-N" among
+// all categories, deleted ones included since the column is unique. Purely
+// numeric slugs are prefixed, GET /categories/:id reads digits as an ID.
+func UniqueCategorySlug(db *gorm.DB, slug string, categoryID uint) (string, error) {
+	if _, err := strconv.Atoi(slug); err == nil {
+		slug = "category-" + slug
+	}
+
+	candidate := slug
+	for i := 2; ; i++ {
+		var count int64
+		if err := db.Unscoped().Model(&models.Category{}).
+			Where("slug = ? AND id <> ?", candidate, categoryID).
+			Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", slug, i)
+	}
+}
+
+// AssignProductSlug sets product.Slug from the requested slug, or from the
+// name when none is requested. Call it on create and when the name or the
+// requested slug changes. If the product already had a different slug,
+// the old one is kept in the history so its URL keeps redirecting. The
+// product itself is not saved.
+func AssignProductSlug(tx *gorm.DB, product *models.Product, requested string) error {
+	base := Slugify(requested)
+	if base == "" {
+		base = Slugify(product.Name)
+	}
+	slug, err := uniqueProductSlug(tx, base, product.ID)
+	if err != nil {
+		return err
+	}
+	if slug == product.Slug {
+		return nil
+	}
+
+	if product.Slug != "" {
+		if err := tx.Create(&models.ProductSlugHistory{
+			ProductID: product.ID,
+			Slug:      product.Slug,
+		}).Error; err != nil {
+			return err
+		}
+	}
+	// taking back one of its own old slugs
+	if err := tx.Where("product_id = ? AND slug = ?", product.ID, slug).
+		Delete(&models.ProductSlugHistory{}).Error; err != nil {
+		return err
+	}
+	product.Slug = slug
+	return nil
+}
+
+// ProductIDOrSlug matches a product by numeric ID or by its current slug
+func ProductIDOrSlug(idOrSlug string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if _, err := strconv.Atoi(idOrSlug); err == nil {
+			return db.Where("products.id = ?", idOrSlug)
+		}
+		return db.Where("products.slug = ?", idOrSlug)
+	}
+}
@@ -0,0 +1,111 @@
+package helper
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"words", "Hello World", "hello-world"},
+		{"symbols collapse", "  A & B -- C!  ", "a-b-c"},
+		{"digits kept", "iPhone 15 Pro", "iphone-15-pro"},
+		{"accents dropped", "Café Crème", "caf-cr-me"},
+		{"nothing usable", "--- !!! ---", ""},
+		{"empty", "", ""},
+		{"capped at 200", strings.Repeat("a", 250), strings.Repeat("a", 200)},
+		{"no dash left at the cut", strings.Repeat("a", 199) + " bcd", strings.Repeat("a", 199)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.in); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueProductSlug(t *testing.T) {
+	db := testDB(t)
+
+	product := models.Product{UserID: 1, VendorID: 1, CategoryID: 1, Name: "Shoe", Slug: "shoe"}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	// the product was called "Boot" before
+	if err := db.Create(&models.ProductSlugHistory{ProductID: product.ID, Slug: "boot"}).Error; err != nil {
+		t.Fatalf("failed to create slug history: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		slug      string
+		productID uint
+		want      string
+	}{
+		{"free", "sandal", 0, "sandal"},
+		{"taken by a product", "shoe", 0, "shoe-2"},
+		{"own slug", "shoe", product.ID, "shoe"},
+		{"old slug of another product", "boot", 0, "boot-2"},
+		{"own old slug", "boot", product.ID, "boot"},
+		{"empty", "", 0, "product"},
+		{"numeric", "123", 0, "product-123"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uniqueProductSlug(db, tt.slug, tt.productID)
+			if err != nil {
+				t.Fatalf("uniqueProductSlug failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("uniqueProductSlug(%q) = %q, want %q", tt.slug, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUniqueCategorySlug(t *testing.T) {
+	db := testDB(t)
+
+	existing := []models.Category{
+		{Name: "A B", Slug: "a-b"},
+		{Name: "A & B 2", Slug: "a-b-2"},
+		{Name: "Old", Slug: "old"},
+	}
+	if err := db.Create(&existing).Error; err != nil {
+		t.Fatalf("failed to create categories: %v", err)
+	}
+	if err := db.Delete(&existing[2]).Error; err != nil {
+		t.Fatalf("failed to delete category: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		slug       string
+		categoryID uint
+		want       string
+	}{
+		{"free", "shoes", 0, "shoes"},
+		{"taken twice", "a-b", 0, "a-b-3"},
+		{"own slug", "a-b", existing[0].ID, "a-b"},
+		{"taken by a deleted category", "old", 0, "old-2"},
+		{"numeric", "2024", 0, "category-2024"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UniqueCategorySlug(db, tt.slug, tt.categoryID)
+			if err != nil {
+				t.Fatalf("UniqueCategorySlug failed: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("UniqueCategorySlug(%q) = %q, want %q", tt.slug, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package helper
+
+import (
+	"errors"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrInsufficientStock is returned when a movement would take stock below zero
+var ErrInsufficientStock = errors.New("insufficient stock")
+
+// ApplyStockMovement changes the product's stock by movement.Quantity and
+// appends the movement to the ledger. It should run inside a transaction so
+// the stock change and its ledger entry are stored together.
+//
+// The change is a single conditional UPDATE, so concurrent checkouts can never
+// take stock below zero: the losing one gets ErrInsufficientStock. Bundles
+// containing the product are re-synced; bundles themselves get ErrBundleStock.
+func ApplyStockMovement(tx *gorm.DB, movement *models.StockMovement) error {
+	var product models.Product
+	result := tx.Model(&product).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "stock"}}}).
+		Where("id = ? AND type <> ? AND stock + ? >= 0", movement.ProductID, models.ProductTypeBundle, movement.Quantity).
+		Updates(map[string]interface{}{
+			"stock": gorm.Expr("stock + ?", movement.Quantity),
+			// restocking above the threshold re-arms the low-stock alert
+			"low_stock_alerted_at": gorm.Expr("CASE WHEN stock + ? > reorder_threshold THEN NULL ELSE low_stock_alerted_at END", movement.Quantity),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		var existing models.Product
+		if err := tx.Select("id", "type").First(&existing, movement.ProductID).Error; err != nil {
+			return err
+		}
+		if existing.Type == models.ProductTypeBundle {
+			return ErrBundleStock
+		}
+		return ErrInsufficientStock
+	}
+
+	movement.StockAfter = product.Stock
+	if err := tx.Create(movement).Error; err != nil {
+		return err
+	}
+	if err := SyncBundlesOf(tx, movement.ProductID); err != nil {
+		return err
+	}
+
+	// back in stock: hand the waiting subscriptions to the notifier job
+	if movement.StockAfter > 0 && movement.StockAfter-movement.Quantity <= 0 {
+		return tx.Model(&models.StockSubscription{}).
+			Where("product_id = ? AND triggered_at IS NULL", movement.ProductID).
+			Update("triggered_at", time.Now()).Error
+	}
+	return nil
+}
+
+// LedgerBalance returns the sum of the ledger movements of a product, minus
+// the resets to the ledger balance
+func LedgerBalance(db *gorm.DB, productID uint) (int, error) {
+	var balance int
+	err := db.Model(&models.StockMovement{}).
+		Select("COALESCE(SUM(quantity), 0)").
+		Where("product_id = ? AND reference <> ?", productID, models.StockReferenceLedgerReset).
+		Scan(&balance).Error
+	return balance, err
+}
@@ -0,0 +1,103 @@
+package helper
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// TestApplyStockMovementNoOverselling races more checkouts than there is
+// stock: exactly stock of them may succeed and the stock never goes negative.
+func TestApplyStockMovementNoOverselling(t *testing.T) {
+	db := testDB(t)
+
+	const stock = 5
+	const buyers = 40
+
+	product := models.Product{
+		UserID:     1,
+		VendorID:   1,
+		CategoryID: 1,
+		Name:       fmt.Sprintf("oversell test %d", time.Now().UnixNano()),
+		Price:      10,
+		Stock:      stock,
+		Status:     "published",
+	}
+	if err := db.Create(&product).Error; err != nil {
+		t.Fatalf("failed to create product: %v", err)
+	}
+	t.Cleanup(func() {
+		db.Where("product_id = ?", product.ID).Delete(&models.StockMovement{})
+		db.Unscoped().Delete(&product)
+	})
+
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	errs := make(chan error, buyers)
+	for i := 0; i < buyers; i++ {
+		wg.Add(1)
+		go func(buyer int) {
+			defer wg.Done()
+			<-start
+			errs <- db.Transaction(func(tx *gorm.DB) error {
+				return ApplyStockMovement(tx, &models.StockMovement{
+					ProductID: product.ID,
+					Type:      models.StockMovementSale,
+					Quantity:  -1,
+					Reason:    "order placed",
+					Reference: fmt.Sprintf("order:test-%d", buyer),
+				})
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	close(errs)
+
+	succeeded, soldOut := 0, 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			succeeded++
+		case errors.Is(err, ErrInsufficientStock):
+			soldOut++
+		default:
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if succeeded != stock {
+		t.Errorf("%d checkouts succeeded, want %d", succeeded, stock)
+	}
+	if soldOut != buyers-stock {
+		t.Errorf("%d checkouts were refused, want %d", soldOut, buyers-stock)
+	}
+
+	var final models.Product
+	if err := db.First(&final, product.ID).Error; err != nil {
+		t.Fatalf("failed to reload product: %v", err)
+	}
+	if final.Stock != 0 {
+		t.Errorf("final stock is %d, want 0", final.Stock)
+	}
+
+	var movements []models.StockMovement
+	if err := db.Where("product_id = ?", product.ID).Find(&movements).Error; err != nil {
+		t.Fatalf("failed to load ledger: %v", err)
+	}
+	if len(movements) != stock {
+		t.Errorf("ledger has %d movements, want %d", len(movements), stock)
+	}
+	for _, movement := range movements {
+		if movement.StockAfter < 0 {
+			t.Errorf("movement %d left the stock at %d", movement.ID, movement.StockAfter)
+		}
+	}
+	if balance, err := LedgerBalance(db, product.ID); err != nil || balance != -stock {
+		t.Errorf("ledger balance is %d (%v), want %d", balance, err, -stock)
+	}
+}
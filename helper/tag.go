@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"strings"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ResolveTags returns the tags for the given names, creating the missing
+// ones. Names that slugify to the same tag are merged.
+func ResolveTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		slug := Slugify(name)
+		if slug == "" || seen[slug] {
+			continue
+		}
+		seen[slug] = true
+
+		tag := models.Tag{Name: name, Slug: slug}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&tag).Error; err != nil {
+			return nil, err
+		}
+		if tag.ID == 0 {
+			if err := tx.Where("slug = ?", slug).First(&tag).Error; err != nil {
+				return nil, err
+			}
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// SetProductTags replaces the tags of a product
+func SetProductTags(tx *gorm.DB, product *models.Product, names []string) error {
+	tags, err := ResolveTags(tx, names)
+	if err != nil {
+		return err
+	}
+	return tx.Model(product).Association("Tags").Replace(tags)
+}
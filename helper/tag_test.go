@@ -0,0 +1,42 @@
+package helper
+
+import (
+	"testing"
+)
+
+func TestResolveTags(t *testing.T) {
+	db := testDB(t)
+
+	tests := []struct {
+		name  string
+		names []string
+		want  []string // slugs, in order
+	}{
+		{"new tags", []string{"Summer Sale", "Winter"}, []string{"summer-sale", "winter"}},
+		{"same slug once", []string{"Summer Sale", " summer-sale ", "SUMMER  SALE"}, []string{"summer-sale"}},
+		{"unusable names dropped", []string{"", "   ", "!!!", "Gift"}, []string{"gift"}},
+		{"nothing usable", []string{"---"}, []string{}},
+	}
+	ids := map[string]uint{}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := ResolveTags(db, tt.names)
+			if err != nil {
+				t.Fatalf("ResolveTags failed: %v", err)
+			}
+			if len(tags) != len(tt.want) {
+				t.Fatalf("got %d tags, want %v", len(tags), tt.want)
+			}
+			for i, tag := range tags {
+				if tag.Slug != tt.want[i] {
+					t.Errorf("tag %d has slug %q, want %q", i, tag.Slug, tt.want[i])
+				}
+				// a known slug resolves to the existing tag
+				if id, found := ids[tag.Slug]; found && id != tag.ID {
+					t.Errorf("tag %q resolved to ID %d, earlier %d", tag.Slug, tag.ID, id)
+				}
+				ids[tag.Slug] = tag.ID
+			}
+		})
+	}
+}
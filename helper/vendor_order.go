@@ -0,0 +1,138 @@
+package helper
+
+import (
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SplitOrderByVendor creates one vendor order per vendor of the order's items
+// and links the items to it. vendorOf maps the product IDs to their vendor.
+func SplitOrderByVendor(tx *gorm.DB, order *models.Order, vendorOf map[uint]uint) error {
+	var vendorIDs []uint
+	itemsOf := make(map[uint][]int)
+	for i, item := range order.Items {
+		vendorID := vendorOf[item.ProductID]
+		if _, found := itemsOf[vendorID]; !found {
+			vendorIDs = append(vendorIDs, vendorID)
+		}
+		itemsOf[vendorID] = append(itemsOf[vendorID], i)
+	}
+
+	order.VendorOrders = order.VendorOrders[:0]
+	for _, vendorID := range vendorIDs {
+		vendorOrder := models.VendorOrder{
+			OrderID:         order.ID,
+			VendorID:        vendorID,
+			Status:          order.Status,
+			ShippingAddress: order.ShippingAddress,
+		}
+		itemIDs := make([]uint, 0, len(itemsOf[vendorID]))
+		for _, i := range itemsOf[vendorID] {
+			vendorOrder.Subtotal += order.Items[i].UnitPrice * float64(order.Items[i].Quantity)
+			itemIDs = append(itemIDs, order.Items[i].ID)
+		}
+		if err := tx.Create(&vendorOrder).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.OrderItem{}).Where("id IN ?", itemIDs).
+			Update("vendor_order_id", vendorOrder.ID).Error; err != nil {
+			return err
+		}
+		for _, i := range itemsOf[vendorID] {
+			order.Items[i].VendorOrderID = &vendorOrder.ID
+		}
+		order.VendorOrders = append(order.VendorOrders, vendorOrder)
+	}
+	return nil
+}
+
+// orderProgress ranks the statuses an order and its vendor orders move
+// through; accepted and packed are still processing from the customer's
+// point of view. Cancelled and refund states are outside of it.
+var orderProgress = map[models.OrderStatus]int{
+	models.OrderStatusPending:       0,
+	models.OrderStatusPaymentFailed: 0,
+	models.OrderStatusPaid:          1,
+	models.OrderStatusProcessing:    2,
+	models.OrderStatusAccepted:      2,
+	models.OrderStatusPacked:        2,
+	models.OrderStatusShipped:       3,
+	models.OrderStatusDelivered:     4,
+}
+
+// SyncOrderStatus moves an order forward with its vendor orders: a paid
+// order is processing once a vendor accepted its part, and shipped once
+// every part is shipped, and so on. Cancelled parts are left out; an order
+// never moves back.
+func SyncOrderStatus(tx *gorm.DB, orderID uint, actorID *uint) error {
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+		return err
+	}
+
+	var statuses []models.OrderStatus
+	if err := tx.Model(&models.VendorOrder{}).
+		Where("order_id = ? AND status <> ?", orderID, models.OrderStatusCancelled).
+		Pluck("status", &statuses).Error; err != nil {
+		return err
+	}
+	if len(statuses) == 0 {
+		return nil
+	}
+
+	reached, started := orderProgress[models.OrderStatusDelivered], false
+	for _, status := range statuses {
+		reached = min(reached, orderProgress[status])
+		started = started || orderProgress[status] >= orderProgress[models.OrderStatusAccepted]
+	}
+
+	if started && order.Status == models.OrderStatusPaid {
+		if err := SetOrderStatus(tx, &order, models.OrderStatusProcessing, actorID, "a vendor accepted its part"); err != nil {
+			return err
+		}
+	}
+
+	for _, status := range []models.OrderStatus{models.OrderStatusShipped, models.OrderStatusDelivered} {
+		if orderProgress[status] > reached || !CanTransitionOrder(order.Status, status) {
+			continue
+		}
+		if err := SetOrderStatus(tx, &order, status, actorID, "every vendor order is "+string(status)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CascadeOrderStatus carries a status set on an order over to its vendor
+// orders that are behind it. Cancelling or refunding applies to every part
+// that isn't cancelled yet. Each vendor order changed gets a history entry.
+func CascadeOrderStatus(tx *gorm.DB, orderID uint, status models.OrderStatus, actorID *uint, note string) error {
+	query := tx.Model(&models.VendorOrder{}).Where("order_id = ? AND status <> ?", orderID, models.OrderStatusCancelled)
+	if progress, ok := orderProgress[status]; ok {
+		var behind []models.OrderStatus
+		for vendorStatus, vendorProgress := range orderProgress {
+			if vendorProgress < progress {
+				behind = append(behind, vendorStatus)
+			}
+		}
+		if len(behind) == 0 {
+			return nil
+		}
+		query = query.Where("status IN ?", behind)
+	}
+
+	var vendorOrders []models.VendorOrder
+	if err := query.Select("id", "status").Find(&vendorOrders).Error; err != nil {
+		return err
+	}
+	for _, vendorOrder := range vendorOrders {
+		if err := tx.Model(&vendorOrder).Update("status", status).Error; err != nil {
+			return err
+		}
+		if err := RecordOrderStatus(tx, orderID, &vendorOrder.ID, vendorOrder.Status, status, actorID, note); err != nil {
+			return err
+		}
+	}
+	return nil
+}
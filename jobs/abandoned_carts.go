@@ -0,0 +1,153 @@
+package jobs
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"github.com/abdullahalsazib/e-com-backend/utils"
+	"gorm.io/gorm"
+)
+
+// StartAbandonedCartNotifier periodically emails users whose carts have been
+// idle through the next step of helper.CartReminderSchedule, and deletes carts
+// idle for longer than helper.CartPurgeAge. At most ABANDONED_CART_BATCH_SIZE
+// reminders (default 50) are sent per run to throttle outgoing email.
+func StartAbandonedCartNotifier(db *gorm.DB, interval time.Duration) {
+	batchSize, err := strconv.Atoi(os.Getenv("ABANDONED_CART_BATCH_SIZE"))
+	if err != nil || batchSize <= 0 {
+		batchSize = 50
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := RemindAbandonedCarts(db, batchSize); err != nil {
+				log.Printf("Failed to send abandoned cart reminders: %v", err)
+			}
+			if err := PurgeAbandonedCarts(db); err != nil {
+				log.Printf("Failed to purge abandoned carts: %v", err)
+			}
+		}
+	}()
+}
+
+// RemindAbandonedCarts sends up to batchSize reminders. Only carts of users
+// with something in them are reminded; any cart activity, checking out
+// included, starts the sequence over.
+func RemindAbandonedCarts(db *gorm.DB, batchSize int) error {
+	schedule := helper.CartReminderSchedule()
+	now := time.Now()
+
+	for step := len(schedule) - 1; step >= 0 && batchSize > 0; step-- {
+		// a cart idle past a later step skips the reminders it missed
+		var carts []models.Cart
+		query := db.Preload("CartItems", "saved_for_later = ?", false).Preload("CartItems.Product", helper.CustomerVisible).
+			Where("user_id IS NOT NULL AND reminders_sent <= ?", step).
+			Where("COALESCE(last_activity_at, updated_at) <= ?", now.Add(-schedule[step])).
+			Where("EXISTS (SELECT 1 FROM cart_items WHERE cart_items.cart_id = carts.id AND cart_items.saved_for_later = ? AND cart_items.deleted_at IS NULL)", false)
+		// carts that went idle long before reminders were on are left alone
+		until := 2 * schedule[step]
+		if step+1 < len(schedule) {
+			until = schedule[step+1]
+		}
+		query = query.Where("COALESCE(last_activity_at, updated_at) > ?", now.Add(-until))
+		if err := query.Order("id").Limit(batchSize).Find(&carts).Error; err != nil {
+			return err
+		}
+
+		for _, cart := range carts {
+			if err := remindCart(db, &cart, step+1); err != nil {
+				log.Printf("Failed to remind cart %d: %v", cart.ID, err)
+				continue
+			}
+			batchSize--
+		}
+	}
+
+	return nil
+}
+
+func remindCart(db *gorm.DB, cart *models.Cart, step int) error {
+	var user models.User
+	if err := db.Select("id", "email", "name").First(&user, *cart.UserID).Error; err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(cart.CartItems))
+	for _, item := range cart.CartItems {
+		if item.Product.ID != 0 {
+			names = append(names, html.EscapeString(item.Product.Name))
+		}
+	}
+
+	reminder := models.CartReminder{CartID: cart.ID, UserID: user.ID, Step: step, SentAt: time.Now()}
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&reminder).Error; err != nil {
+			return err
+		}
+		return tx.Model(cart).UpdateColumn("reminders_sent", step).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	// nothing left that can be bought: count the step but don't send it
+	if len(names) == 0 {
+		return nil
+	}
+
+	link := html.EscapeString(helper.CartRecoveryURL(reminder.ID))
+	body := fmt.Sprintf("<p>Hi %s,</p><p>You left these in your cart: %s.</p><p><a href=\"%s\">Complete your order</a></p>",
+		html.EscapeString(user.Name), strings.Join(names, ", "), link)
+	go func(email string) {
+		if err := utils.SendEmail(email, "You left something in your cart", body); err != nil {
+			log.Printf("Failed to send abandoned cart email for cart %d: %v", cart.ID, err)
+		}
+	}(user.Email)
+
+	return nil
+}
+
+// PurgeAbandonedCarts deletes carts, guest carts included, that have been
+// idle for longer than helper.CartPurgeAge, with their items and reminders.
+// Reminders that recovered an order are kept for the attribution.
+func PurgeAbandonedCarts(db *gorm.DB) error {
+	cutoff := time.Now().Add(-helper.CartPurgeAge())
+
+	var cartIDs []uint
+	if err := db.Unscoped().Model(&models.Cart{}).
+		Where("COALESCE(last_activity_at, updated_at) < ?", cutoff).
+		Pluck("id", &cartIDs).Error; err != nil {
+		return err
+	}
+	if len(cartIDs) == 0 {
+		return nil
+	}
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("cart_id IN ?", cartIDs).Delete(&models.CartItem{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("cart_id IN ?", cartIDs).
+			Where("id NOT IN (SELECT recovery_reminder_id FROM orders WHERE recovery_reminder_id IS NOT NULL)").
+			Delete(&models.CartReminder{}).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Where("id IN ?", cartIDs).Delete(&models.Cart{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Purged %d abandoned carts", len(cartIDs))
+	return nil
+}
@@ -0,0 +1,84 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// StartBackInStockNotifier periodically emails users whose subscribed
+// products are back in stock. At most BACK_IN_STOCK_BATCH_SIZE subscriptions
+// (default 50) are handled per run to throttle outgoing email.
+func StartBackInStockNotifier(db *gorm.DB, interval time.Duration) {
+	batchSize, err := strconv.Atoi(os.Getenv("BACK_IN_STOCK_BATCH_SIZE"))
+	if err != nil || batchSize <= 0 {
+		batchSize = 50
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := NotifyBackInStock(db, batchSize); err != nil {
+				log.Printf("Failed to send back-in-stock notifications: %v", err)
+			}
+		}
+	}()
+}
+
+// NotifyBackInStock sends one email per user for up to batchSize triggered
+// subscriptions and removes them afterwards
+func NotifyBackInStock(db *gorm.DB, batchSize int) error {
+	var subscriptions []models.StockSubscription
+	if err := db.Preload("Product", helper.CustomerVisible).
+		Where("triggered_at IS NOT NULL").
+		Order("triggered_at ASC").
+		Limit(batchSize).
+		Find(&subscriptions).Error; err != nil {
+		return err
+	}
+
+	byUser := make(map[uint][]models.StockSubscription)
+	for _, subscription := range subscriptions {
+		// sold out again before we got to it: wait for the next restock
+		if subscription.Product == nil || subscription.Product.Stock <= 0 {
+			db.Model(&subscription).Update("triggered_at", nil)
+			continue
+		}
+		byUser[subscription.UserID] = append(byUser[subscription.UserID], subscription)
+	}
+
+	for userID, userSubscriptions := range byUser {
+		names := make([]string, 0, len(userSubscriptions))
+		ids := make([]uint, 0, len(userSubscriptions))
+		productIDs := make([]uint, 0, len(userSubscriptions))
+		for _, subscription := range userSubscriptions {
+			names = append(names, subscription.Product.Name)
+			ids = append(ids, subscription.ID)
+			productIDs = append(productIDs, subscription.ProductID)
+		}
+
+		message := "Good news! These products are back in stock: " + strings.Join(names, ", ")
+		if err := helper.Notify(db, userID, "back_in_stock", "Back in stock", message,
+			fmt.Sprintf("product:%d", productIDs[0]), true); err != nil {
+			log.Printf("Failed to notify user %d about restocked products: %v", userID, err)
+			continue
+		}
+
+		// notified once, so unsubscribe automatically
+		db.Where("id IN ?", ids).Delete(&models.StockSubscription{})
+		db.Model(&models.WishlistItem{}).
+			Where("user_id = ? AND product_id IN ?", userID, productIDs).
+			Update("notify_when_in_stock", false)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"gorm.io/gorm"
+)
+
+// StartPriceDropNotifier periodically looks for wishlisted products that got
+// cheaper and alerts the users who opted in.
+func StartPriceDropNotifier(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := helper.NotifyPriceDrops(db); err != nil {
+				log.Printf("Failed to send price drop alerts: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,120 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// StartPriceScheduler periodically starts and ends scheduled prices
+func StartPriceScheduler(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := ApplyPriceSchedules(db); err != nil {
+				log.Printf("Failed to apply price schedules: %v", err)
+			}
+		}
+	}()
+}
+
+// ApplyPriceSchedules reverts schedules that ended and applies those that are due
+func ApplyPriceSchedules(db *gorm.DB) error {
+	now := time.Now()
+
+	// open-ended schedules started before they were completed on start would
+	// stay active forever and block new schedules
+	if err := db.Model(&models.PriceSchedule{}).
+		Where("status = ? AND ends_at IS NULL", models.PriceScheduleActive).
+		Update("status", models.PriceScheduleCompleted).Error; err != nil {
+		return err
+	}
+
+	// end first, so a sale ending and the next one starting apply in order
+	var ending []models.PriceSchedule
+	if err := db.Where("status = ? AND ends_at <= ?", models.PriceScheduleActive, now).
+		Find(&ending).Error; err != nil {
+		return err
+	}
+	for _, schedule := range ending {
+		if err := endPriceSchedule(db, schedule); err != nil {
+			log.Printf("Failed to end price schedule %d: %v", schedule.ID, err)
+		}
+	}
+
+	var starting []models.PriceSchedule
+	if err := db.Where("status = ? AND starts_at <= ?", models.PriceScheduleScheduled, now).
+		Order("starts_at ASC").
+		Find(&starting).Error; err != nil {
+		return err
+	}
+
+	var dropped []uint
+	for _, schedule := range starting {
+		// the whole window passed while the worker was down: skip it
+		if schedule.EndsAt != nil && !schedule.EndsAt.After(now) {
+			db.Model(&schedule).Update("status", models.PriceScheduleCompleted)
+			continue
+		}
+		original, err := startPriceSchedule(db, schedule)
+		if err != nil {
+			log.Printf("Failed to start price schedule %d: %v", schedule.ID, err)
+			continue
+		}
+		if schedule.Price < original {
+			dropped = append(dropped, schedule.ProductID)
+		}
+	}
+
+	if len(dropped) > 0 {
+		return helper.NotifyPriceDrops(db, dropped...)
+	}
+	return nil
+}
+
+func startPriceSchedule(db *gorm.DB, schedule models.PriceSchedule) (float64, error) {
+	var product models.Product
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Select("id", "price").First(&product, schedule.ProductID).Error; err != nil {
+			return err
+		}
+		if err := helper.ChangePrice(tx, product.ID, schedule.Price, nil,
+			models.PriceSourceScheduleStart, &schedule.ID); err != nil {
+			return err
+		}
+
+		// a temporary sale shows the regular price as compare-at price
+		var compareAt *float64
+		if schedule.EndsAt != nil && schedule.Price < product.Price {
+			compareAt = &product.Price
+		}
+		if err := tx.Model(&product).Update("compare_at_price", compareAt).Error; err != nil {
+			return err
+		}
+
+		// a schedule without an end is a permanent price change, done once applied
+		status := models.PriceScheduleActive
+		if schedule.EndsAt == nil {
+			status = models.PriceScheduleCompleted
+		}
+		return tx.Model(&schedule).Updates(map[string]interface{}{
+			"status":         status,
+			"original_price": product.Price,
+		}).Error
+	})
+	return product.Price, err
+}
+
+func endPriceSchedule(db *gorm.DB, schedule models.PriceSchedule) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if _, err := helper.RestoreSchedulePrice(tx, &schedule, nil); err != nil {
+			return err
+		}
+		return tx.Model(&schedule).Update("status", models.PriceScheduleCompleted).Error
+	})
+}
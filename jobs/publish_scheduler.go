@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// StartPublishScheduler periodically publishes and unpublishes products
+// according to their publish_at/unpublish_at times
+func StartPublishScheduler(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := ApplyPublishSchedules(db); err != nil {
+				log.Printf("Failed to apply publish schedules: %v", err)
+			}
+		}
+	}()
+}
+
+// ApplyPublishSchedules moves due drafts to published and expired products to archived
+func ApplyPublishSchedules(db *gorm.DB) error {
+	now := time.Now()
+
+	var unpublishing []models.Product
+	if err := db.Where("status = ? AND unpublish_at <= ?", "published", now).
+		Find(&unpublishing).Error; err != nil {
+		return err
+	}
+	for _, product := range unpublishing {
+		if err := transitionProduct(db, product, "archived", "scheduled_unpublish", map[string]interface{}{
+			"unpublish_at": nil,
+		}); err != nil {
+			log.Printf("Failed to unpublish product %d: %v", product.ID, err)
+		}
+	}
+
+	var publishing []models.Product
+	if err := db.Where("status = ? AND publish_at <= ?", "draft", now).
+		Find(&publishing).Error; err != nil {
+		return err
+	}
+	for _, product := range publishing {
+		// with moderation on the due draft goes to the review queue instead
+		status := helper.PublishStatus(product.Status, "published")
+		// the unpublish time passed as well while the worker was down
+		if product.UnpublishAt != nil && !product.UnpublishAt.After(now) {
+			status = "archived"
+		}
+		if err := transitionProduct(db, product, status, "scheduled_publish", map[string]interface{}{
+			"publish_at": nil,
+		}); err != nil {
+			log.Printf("Failed to publish product %d: %v", product.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// transitionProduct changes the status (only if nobody changed it meanwhile) and writes the audit entry
+func transitionProduct(db *gorm.DB, product models.Product, status, action string, extra map[string]interface{}) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		updates := map[string]interface{}{"status": status}
+		for column, value := range extra {
+			updates[column] = value
+		}
+
+		result := tx.Model(&models.Product{}).
+			Where("id = ? AND status = ?", product.ID, product.Status).
+			Updates(updates)
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		oldValJSON, _ := json.Marshal(map[string]string{"status": product.Status})
+		newValJSON, _ := json.Marshal(map[string]string{"status": status})
+		return tx.Create(&models.AuditLog{
+			Action:   action,
+			Resource: fmt.Sprintf("product:%d", product.ID),
+			OldValue: string(oldValJSON),
+			NewValue: string(newValJSON),
+		}).Error
+	})
+}
@@ -0,0 +1,115 @@
+package jobs
+
+import (
+	"log"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// recommendationsPerKind is how many recommendations of each kind are kept per
+// product. More than are served, so hidden or sold out ones can be skipped.
+const recommendationsPerKind = 20
+
+// similarCandidatesPerProduct caps the products scored as similar to each
+// product, per source (same category, shared tags)
+const similarCandidatesPerProduct = 200
+
+// StartRecommendationBuilder rebuilds the recommendation table right away and
+// then on every interval.
+func StartRecommendationBuilder(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			if err := ComputeRecommendations(db); err != nil {
+				log.Printf("Failed to compute recommendations: %v", err)
+			}
+			<-ticker.C
+		}
+	}()
+}
+
+// ComputeRecommendations replaces the precomputed recommendations:
+//   - bought_together: products ordered together, scored by the number of
+//     orders (cancelled ones excluded) containing both
+//   - similar: products of the same category and/or sharing tags, scored by
+//     the number of shared tags plus one for the same category
+func ComputeRecommendations(db *gorm.DB) error {
+	now := time.Now()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&models.ProductRecommendation{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec(`
+			INSERT INTO product_recommendations (product_id, recommended_id, kind, score, rank, computed_at)
+			SELECT product_id, recommended_id, ?, score, rank, ?
+			FROM (
+				SELECT a.product_id, b.product_id AS recommended_id,
+					COUNT(DISTINCT a.order_id) AS score,
+					ROW_NUMBER() OVER (PARTITION BY a.product_id
+						ORDER BY COUNT(DISTINCT a.order_id) DESC, b.product_id) AS rank
+				FROM order_items a
+				JOIN order_items b ON b.order_id = a.order_id AND b.product_id <> a.product_id
+					AND b.deleted_at IS NULL
+				JOIN orders ON orders.id = a.order_id AND orders.deleted_at IS NULL
+					AND orders.status <> ?
+				WHERE a.deleted_at IS NULL
+				GROUP BY a.product_id, b.product_id
+			) pairs
+			WHERE rank <= ?`,
+			models.RecommendationBoughtTogether, now, models.OrderStatusCancelled, recommendationsPerKind,
+		).Error; err != nil {
+			return err
+		}
+
+		// similar products are only looked for among a capped set of candidates
+		// per product, the newest of its category and those sharing the most
+		// tags, instead of scoring every pair of products in the catalog
+		return tx.Exec(`
+			WITH shared_tags AS (
+				SELECT pt.product_id, qt.product_id AS recommended_id, COUNT(*) AS tags
+				FROM product_tags pt
+				JOIN product_tags qt ON qt.tag_id = pt.tag_id AND qt.product_id <> pt.product_id
+				GROUP BY pt.product_id, qt.product_id
+			), candidates AS (
+				SELECT p.id AS product_id, same.id AS recommended_id
+				FROM products p
+				CROSS JOIN LATERAL (
+					SELECT q.id FROM products q
+					WHERE q.category_id = p.category_id AND q.id <> p.id AND q.deleted_at IS NULL
+					ORDER BY q.created_at DESC, q.id
+					LIMIT ?
+				) same
+				WHERE p.deleted_at IS NULL
+				UNION
+				SELECT product_id, recommended_id
+				FROM (
+					SELECT product_id, recommended_id,
+						ROW_NUMBER() OVER (PARTITION BY product_id ORDER BY tags DESC, recommended_id) AS n
+					FROM shared_tags
+				) tagged
+				WHERE n <= ?
+			)
+			INSERT INTO product_recommendations (product_id, recommended_id, kind, score, rank, computed_at)
+			SELECT product_id, recommended_id, ?, score, rank, ?
+			FROM (
+				SELECT p.id AS product_id, q.id AS recommended_id,
+					COALESCE(st.tags, 0) + CASE WHEN p.category_id = q.category_id THEN 1 ELSE 0 END AS score,
+					ROW_NUMBER() OVER (PARTITION BY p.id
+						ORDER BY COALESCE(st.tags, 0) + CASE WHEN p.category_id = q.category_id THEN 1 ELSE 0 END DESC,
+						q.created_at DESC, q.id) AS rank
+				FROM candidates
+				JOIN products p ON p.id = candidates.product_id AND p.deleted_at IS NULL
+				JOIN products q ON q.id = candidates.recommended_id AND q.deleted_at IS NULL
+				LEFT JOIN shared_tags st ON st.product_id = p.id AND st.recommended_id = q.id
+			) similar
+			WHERE rank <= ?`,
+			similarCandidatesPerProduct, similarCandidatesPerProduct,
+			models.RecommendationSimilar, now, recommendationsPerKind,
+		).Error
+	})
+}
@@ -0,0 +1,94 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StartReservationReleaser periodically cancels unpaid orders whose stock
+// reservation expired and puts the reserved stock back. Orders paid on
+// delivery are never expired.
+func StartReservationReleaser(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := ReleaseExpiredReservations(db); err != nil {
+				log.Printf("Failed to release expired reservations: %v", err)
+			}
+		}
+	}()
+}
+
+// ReleaseExpiredReservations releases every active reservation past its expiry
+func ReleaseExpiredReservations(db *gorm.DB) error {
+	var orderIDs []uint
+	if err := db.Model(&models.StockReservation{}).
+		Where("status = ? AND expires_at <= ?", models.ReservationActive, time.Now()).
+		Distinct().Pluck("order_id", &orderIDs).Error; err != nil {
+		return err
+	}
+
+	for _, orderID := range orderIDs {
+		if err := releaseOrder(db, orderID); err != nil {
+			log.Printf("Failed to release reservation of order %d: %v", orderID, err)
+		}
+	}
+	return nil
+}
+
+func releaseOrder(db *gorm.DB, orderID uint) error {
+	var order models.Order
+	expired := false
+
+	err := db.Transaction(func(tx *gorm.DB) error {
+		// lock the order so a concurrent cancel or status update waits for us
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			return err
+		}
+
+		// the order moved on (paid or cancelled) without touching the reservation
+		if !helper.IsUnpaid(order.Status) {
+			if order.Status == models.OrderStatusCancelled {
+				return helper.ReleaseOrderStock(tx, &order, nil, "reservation released")
+			}
+			return helper.CommitReservations(tx, order.ID)
+		}
+
+		// placed before pay on delivery orders got committed stock: nothing
+		// to wait for, so keep the order and its stock
+		if helper.PaysOnDelivery(order.PaymentMethod) {
+			if err := tx.Model(&order).Update("reserved_until", nil).Error; err != nil {
+				return err
+			}
+			return helper.CommitReservations(tx, order.ID)
+		}
+
+		expired = true
+		if err := helper.SetOrderStatus(tx, &order, models.OrderStatusCancelled, nil, "reservation expired before payment"); err != nil {
+			return err
+		}
+		if err := helper.CascadeOrderStatus(tx, order.ID, models.OrderStatusCancelled, nil, "reservation expired before payment"); err != nil {
+			return err
+		}
+		return helper.ReleaseOrderStock(tx, &order, nil, "reservation expired before payment")
+	})
+	if err != nil {
+		return err
+	}
+
+	if expired {
+		helper.Notify(db, order.UserID, "order_expired",
+			"Your order was cancelled",
+			fmt.Sprintf("Order #%d was not paid in time and has been cancelled.", order.ID),
+			fmt.Sprintf("order:%d", order.ID), false)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package jobs
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// StartViewHistoryPruner periodically deletes browsing history older than the
+// retention period.
+func StartViewHistoryPruner(db *gorm.DB, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			if err := PruneViewHistory(db); err != nil {
+				log.Printf("Failed to prune browsing history: %v", err)
+			}
+		}
+	}()
+}
+
+// PruneViewHistory deletes views older than RECENTLY_VIEWED_RETENTION_DAYS
+// (default 90). Aggregated view counts are kept.
+func PruneViewHistory(db *gorm.DB) error {
+	days, err := strconv.Atoi(os.Getenv("RECENTLY_VIEWED_RETENTION_DAYS"))
+	if err != nil || days < 1 {
+		days = 90
+	}
+
+	result := db.Where("viewed_at < ?", time.Now().AddDate(0, 0, -days)).Delete(&models.ProductView{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Pruned %d old product views", result.RowsAffected)
+	}
+	return nil
+}
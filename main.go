@@ -3,11 +3,14 @@ package main
 import (
 	"log"
 	"os"
+	"time"
 
 	"github.com/abdullahalsazib/e-com-backend/config"
+	"github.com/abdullahalsazib/e-com-backend/jobs"
 	"github.com/abdullahalsazib/e-com-backend/models"
 	"github.com/abdullahalsazib/e-com-backend/routes"
 	"github.com/abdullahalsazib/e-com-backend/seed"
+	"github.com/abdullahalsazib/e-com-backend/utils"
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 )
@@ -22,6 +25,7 @@ func main() {
 	if err != nil {
 		log.Fatal("Error loading .env file")
 	}
+	utils.RequireSigningKey()
 	// connect to db
 	config.ConnectDB()
 	db := config.DB
@@ -40,10 +44,38 @@ func main() {
 		&models.Product{},
 		&models.Cart{},
 		&models.CartItem{},
+		&models.CartReminder{},
 		&models.Category{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.VendorOrder{},
+		&models.OrderStatusHistory{},
 		&models.WishlistItem{},
+		&models.Notification{},
+		&models.ProductQuestion{},
+		&models.ProductAnswer{},
+		&models.AnswerVote{},
+		&models.QAReport{},
+		&models.StockMovement{},
+		&models.StockReservation{},
+		&models.StockSubscription{},
+		&models.PriceAlertPreference{},
+		&models.ProductPriceHistory{},
+		&models.PriceSchedule{},
+		&models.ProductModeration{},
+		&models.ProductRevision{},
+		&models.ProductSlugHistory{},
+		&models.Tag{},
+		&models.Collection{},
+		&models.CollectionItem{},
+		&models.ProductRecommendation{},
+		&models.ProductView{},
+		&models.ProductViewStat{},
+		&models.BundleComponent{},
+		&models.OrderItemComponent{},
+		&models.ProductFile{},
+		&models.LicenseKey{},
+		&models.DownloadGrant{},
 	)
 
 	// seed category
@@ -51,6 +83,20 @@ func main() {
 	// seed roles and super admin
 	seed.SeedRoles(db)
 	seed.SeedSuperAdmin(db)
+	// backfill product slugs
+	seed.SeedProductSlugs(db)
+	// split orders placed before vendor orders
+	seed.SeedVendorOrders(db)
+
+	// background workers
+	jobs.StartReservationReleaser(db, time.Minute)
+	jobs.StartBackInStockNotifier(db, 5*time.Minute)
+	jobs.StartPriceDropNotifier(db, time.Hour)
+	jobs.StartPriceScheduler(db, time.Minute)
+	jobs.StartPublishScheduler(db, time.Minute)
+	jobs.StartRecommendationBuilder(db, 6*time.Hour)
+	jobs.StartViewHistoryPruner(db, 24*time.Hour)
+	jobs.StartAbandonedCartNotifier(db, 15*time.Minute)
 
 	// setup models
 	r := routes.SetupRoutes(db)
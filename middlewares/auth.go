@@ -92,3 +92,38 @@ func AdminSellerMiddleware() gin.HandlerFunc {
 
 	}
 }
+
+// OptionalAuthMiddleware identifies the user like AuthMiddleware when a valid
+// bearer token is sent, and lets anonymous requests through otherwise
+func OptionalAuthMiddleware(DB *gorm.DB) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		tokenString, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !found || tokenString == "" {
+			ctx.Next()
+			return
+		}
+
+		claims, err := utils.ParseToken(tokenString)
+		if err != nil {
+			ctx.Next()
+			return
+		}
+
+		var user models.User
+		if err := DB.Preload("Roles").First(&user, claims.UserID).Error; err != nil {
+			ctx.Next()
+			return
+		}
+
+		var roleSlugs []string
+		for _, role := range user.Roles {
+			roleSlugs = append(roleSlugs, role.Slug)
+		}
+
+		ctx.Set("currentUser", &user)
+		ctx.Set("user_id", user.ID)
+		ctx.Set("role", roleSlugs)
+
+		ctx.Next()
+	}
+}
@@ -0,0 +1,27 @@
+package models
+
+// BundleComponent is one product (with its quantity) contained in a bundle.
+// A bundle has no stock of its own: its Stock is kept at the number of
+// complete bundles the component stock allows.
+type BundleComponent struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	BundleID    uint     `gorm:"not null;uniqueIndex:idx_bundle_component" json:"bundle_id"`
+	ComponentID uint     `gorm:"not null;uniqueIndex:idx_bundle_component;index" json:"component_id"`
+	Component   *Product `gorm:"foreignKey:ComponentID" json:"component,omitempty"`
+	Quantity    int      `gorm:"not null" json:"quantity"`
+}
+
+// OrderItemComponent records what a bundle order item consisted of when it
+// was ordered. Quantity is the total for the order item.
+type OrderItemComponent struct {
+	ID          uint     `gorm:"primaryKey" json:"id"`
+	OrderItemID uint     `gorm:"not null;index" json:"order_item_id"`
+	ProductID   uint     `gorm:"not null" json:"product_id"`
+	Product     *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Quantity    int      `gorm:"not null" json:"quantity"`
+}
+
+type BundleComponentRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,min=1"`
+}
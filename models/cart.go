@@ -1,11 +1,31 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
 
+	"gorm.io/gorm"
+)
+
+// Cart belongs to a user, or to an anonymous visitor holding its signed
+// guest token (see helper.NewCartToken)
 type Cart struct {
 	gorm.Model
-	UserID    uint       `json:"user_id" gorm:"not null"`
-	CartItems []CartItem `json:"items" gorm:"foreignKey:CartID"`
+	UserID     *uint      `json:"user_id" gorm:"index"`
+	GuestToken *string    `json:"-" gorm:"size:64;uniqueIndex"`
+	CartItems  []CartItem `json:"items" gorm:"foreignKey:CartID"`
+	SavedItems []CartItem `json:"saved_items" gorm:"foreignKey:CartID"` // saved for later, not ordered
+
+	LastActivityAt *time.Time `json:"last_activity_at" gorm:"index"`
+	RemindersSent  int        `json:"-" gorm:"default:0"` // abandoned cart reminders since the last activity
+}
+
+// CartReminder is an abandoned cart email; orders it brought back point to it
+type CartReminder struct {
+	ID     uint      `gorm:"primaryKey" json:"id"`
+	CartID uint      `gorm:"index;not null" json:"cart_id"`
+	UserID uint      `gorm:"index;not null" json:"user_id"`
+	Step   int       `gorm:"not null" json:"step"` // 1 for the first reminder of an idle period
+	SentAt time.Time `json:"sent_at"`
 }
 
 type CartItem struct {
@@ -14,6 +34,29 @@ type CartItem struct {
 	ProductID uint    `json:"product_id" gorm:"not null"`
 	Quantity  int     `json:"quantity" gorm:"default:1"`
 	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
+
+	PriceAtAdd    float64 `json:"price_at_add"` // price the user last saw, for change notices
+	SavedForLater bool    `json:"saved_for_later" gorm:"default:false"`
+}
+
+const (
+	CartWarningUnavailable     = "product_unavailable" // deleted or unpublished, item removed
+	CartWarningOutOfStock      = "out_of_stock"
+	CartWarningQuantityReduced = "quantity_reduced"
+	CartWarningPriceChanged    = "price_changed"
+)
+
+// CartWarning tells the user what changed about a cart item since it was added
+type CartWarning struct {
+	Code        string   `json:"code"`
+	ItemID      uint     `json:"item_id"`
+	ProductID   uint     `json:"product_id"`
+	Name        string   `json:"name,omitempty"`
+	Message     string   `json:"message"`
+	OldPrice    *float64 `json:"old_price,omitempty"`
+	NewPrice    *float64 `json:"new_price,omitempty"`
+	OldQuantity *int     `json:"old_quantity,omitempty"`
+	NewQuantity *int     `json:"new_quantity,omitempty"`
 }
 
 type AddToCartRequest struct {
@@ -24,3 +67,7 @@ type AddToCartRequest struct {
 type UpdateCartItemRequest struct {
 	Quantity int `json:"quantity" binding:"required,min=1"`
 }
+
+type MoveToCartRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1"` // defaults to 1
+}
@@ -10,4 +10,30 @@ type Category struct {
 	ImageURL    string    `json:"image_url"`
 	ParentID    *uint     `json:"parent_id"`
 	Parent      *Category `gorm:"foreignKey:ParentID"`
+	SortOrder   int       `json:"sort_order" gorm:"default:0"` // position among its siblings
+}
+
+// CategoryNode is a category in the nested tree with its product counts.
+// TotalProductCount includes the products of all descendants.
+type CategoryNode struct {
+	ID                uint            `json:"id"`
+	Name              string          `json:"name"`
+	Slug              string          `json:"slug"`
+	Description       string          `json:"description"`
+	ImageURL          string          `json:"image_url"`
+	ParentID          *uint           `json:"parent_id"`
+	SortOrder         int             `json:"sort_order"`
+	ProductCount      int             `json:"product_count"`
+	TotalProductCount int             `json:"total_product_count"`
+	Children          []*CategoryNode `json:"children,omitempty"`
+}
+
+type MoveCategoryRequest struct {
+	ParentID *uint `json:"parent_id"`                          // nil moves it to the root
+	Position *int  `json:"position" binding:"omitempty,min=0"` // among the new siblings, default last
+}
+
+type ReorderCategoriesRequest struct {
+	ParentID    *uint  `json:"parent_id"`
+	CategoryIDs []uint `json:"category_ids" binding:"required,min=1"` // siblings in their new order
 }
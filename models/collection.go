@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	CollectionManual = "manual"
+	CollectionRule   = "rule"
+)
+
+// Collection is a curated product grouping across categories. A manual
+// collection lists its products in Items; a rule collection matches every
+// product that satisfies all of its set Rule* fields.
+type Collection struct {
+	gorm.Model
+	Name        string `gorm:"size:150;not null" json:"name"`
+	Slug        string `gorm:"size:170;not null;uniqueIndex:idx_collection_slug,where:deleted_at IS NULL" json:"slug"`
+	Description string `gorm:"type:text" json:"description"`
+	ImageURL    string `json:"image_url"`
+	Type        string `gorm:"size:20;not null" json:"type"` // manual/rule
+	IsActive    bool   `json:"is_active"`
+	SortOrder   int    `json:"sort_order"`
+
+	// shown only between StartsAt and EndsAt when set
+	StartsAt *time.Time `json:"starts_at" gorm:"index"`
+	EndsAt   *time.Time `json:"ends_at" gorm:"index"`
+
+	RuleTagID      *uint    `json:"rule_tag_id"`
+	RuleTag        *Tag     `gorm:"foreignKey:RuleTagID" json:"rule_tag,omitempty"`
+	RuleCategoryID *uint    `json:"rule_category_id"`
+	RuleVendorID   *uint    `json:"rule_vendor_id"`
+	RuleMinPrice   *float64 `json:"rule_min_price"`
+	RuleMaxPrice   *float64 `json:"rule_max_price"`
+
+	CreatedBy uint `json:"created_by"`
+}
+
+// CollectionItem places a product in a manual collection
+type CollectionItem struct {
+	CollectionID uint     `gorm:"primaryKey" json:"collection_id"`
+	ProductID    uint     `gorm:"primaryKey" json:"product_id"`
+	Product      *Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Position     int      `gorm:"not null" json:"position"`
+}
+
+type CollectionRequest struct {
+	Name        string     `json:"name" binding:"required"`
+	Slug        string     `json:"slug"` // optional, generated from the name
+	Description string     `json:"description"`
+	ImageURL    string     `json:"image_url"`
+	Type        string     `json:"type" binding:"required,oneof=manual rule"`
+	IsActive    bool       `json:"is_active"`
+	SortOrder   int        `json:"sort_order"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+
+	RuleTag        string   `json:"rule_tag"` // tag name or slug
+	RuleCategoryID *uint    `json:"rule_category_id"`
+	RuleVendorID   *uint    `json:"rule_vendor_id"`
+	RuleMinPrice   *float64 `json:"rule_min_price"`
+	RuleMaxPrice   *float64 `json:"rule_max_price"`
+}
+
+type SetCollectionProductsRequest struct {
+	ProductIDs []uint `json:"product_ids" binding:"required"` // in display order
+}
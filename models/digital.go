@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// ProductFile is a downloadable file of a digital product. The file lives in
+// private storage and is only served through signed download links.
+type ProductFile struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	ProductID   uint      `gorm:"not null;index" json:"product_id"`
+	FileName    string    `gorm:"size:255;not null" json:"file_name"`
+	StoragePath string    `gorm:"size:255;not null" json:"-"`
+	Size        int64     `json:"size"`
+	ContentType string    `gorm:"size:100" json:"content_type"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// LicenseKey is one key of a product's license-key pool. It is assigned to
+// an order item once the order is paid.
+type LicenseKey struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ProductID   uint       `gorm:"not null;uniqueIndex:idx_license_key_product" json:"product_id"`
+	Key         string     `gorm:"size:255;not null;uniqueIndex:idx_license_key_product" json:"key"`
+	OrderItemID *uint      `gorm:"index" json:"order_item_id"`
+	AssignedAt  *time.Time `json:"assigned_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// DownloadGrant allows the buyer of a digital order item to download the
+// product's files, up to MaxDownloads times (0 = unlimited)
+type DownloadGrant struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	OrderItemID   uint       `gorm:"not null;uniqueIndex" json:"order_item_id"`
+	OrderID       uint       `gorm:"not null;index" json:"order_id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	ProductID     uint       `gorm:"not null" json:"product_id"`
+	Product       *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	DownloadCount int        `gorm:"not null;default:0" json:"download_count"`
+	MaxDownloads  int        `gorm:"not null" json:"max_downloads"`
+	RevokedAt     *time.Time `json:"revoked_at"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+type AddLicenseKeysRequest struct {
+	Keys []string `json:"keys" binding:"required,min=1,dive,required"`
+}
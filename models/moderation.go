@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+const (
+	ProductStatusPendingReview = "pending_review"
+
+	ModerationApproved = "approved"
+	ModerationRejected = "rejected"
+)
+
+// ProductModeration is a superadmin review decision on a product
+type ProductModeration struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ProductID  uint      `gorm:"not null;index" json:"product_id"`
+	Decision   string    `gorm:"size:20;not null" json:"decision"` // approved/rejected
+	Reason     string    `gorm:"type:text" json:"reason"`
+	ReviewerID uint      `gorm:"not null" json:"reviewer_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
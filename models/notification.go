@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Notification is an in-app message shown to a user (vendor or customer)
+type Notification struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	Type      string     `gorm:"size:100" json:"type"` // e.g. "product_question", "question_answered"
+	Title     string     `gorm:"size:255" json:"title"`
+	Message   string     `gorm:"type:text" json:"message"`
+	Resource  string     `gorm:"size:200" json:"resource"` // e.g. "product:12"
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
@@ -12,6 +14,16 @@ const (
 	OrderStatusShipped    OrderStatus = "shipped"
 	OrderStatusDelivered  OrderStatus = "delivered"
 	OrderStatusCancelled  OrderStatus = "cancelled"
+
+	// payment and refund states
+	OrderStatusPaid          OrderStatus = "paid"
+	OrderStatusPaymentFailed OrderStatus = "payment_failed"
+	OrderStatusRefundPending OrderStatus = "refund_pending"
+	OrderStatusRefunded      OrderStatus = "refunded"
+
+	// vendor orders only, between processing and shipped
+	OrderStatusAccepted OrderStatus = "accepted"
+	OrderStatusPacked   OrderStatus = "packed"
 )
 
 type Order struct {
@@ -22,6 +34,45 @@ type Order struct {
 	Items           []OrderItem `json:"items" gorm:"foreignKey:OrderID"`
 	ShippingAddress string      `json:"shipping_address" gorm:"not null"`
 	PaymentMethod   string      `json:"payment_method" gorm:"not null"`
+	ReservedUntil   *time.Time  `json:"reserved_until"` // stock is held until then while payment is pending
+
+	RecoveryReminderID *uint         `json:"recovery_reminder_id,omitempty"` // abandoned cart email that brought the user back
+	RecoveryReminder   *CartReminder `json:"recovery_reminder,omitempty" gorm:"foreignKey:RecoveryReminderID"`
+
+	VendorOrders  []VendorOrder        `json:"vendor_orders" gorm:"foreignKey:OrderID"` // one per vendor, fulfilled separately
+	StatusHistory []OrderStatusHistory `json:"status_history,omitempty" gorm:"foreignKey:OrderID"`
+}
+
+// OrderStatusHistory records every status change of an order, or of one of
+// its vendor orders when VendorOrderID is set
+type OrderStatusHistory struct {
+	ID            uint        `gorm:"primaryKey" json:"id"`
+	OrderID       uint        `gorm:"index;not null" json:"order_id"`
+	VendorOrderID *uint       `gorm:"index" json:"vendor_order_id,omitempty"`
+	FromStatus    OrderStatus `gorm:"type:varchar(20)" json:"from_status"` // empty when the order was placed
+	ToStatus      OrderStatus `gorm:"type:varchar(20);not null" json:"to_status"`
+	ActorID       *uint       `json:"actor_id"` // nil for the system
+	Note          string      `gorm:"type:text" json:"note"`
+	CreatedAt     time.Time   `json:"created_at"`
+}
+
+// VendorOrder is the part of an order one vendor fulfils: the items of its
+// products, with their own status
+type VendorOrder struct {
+	gorm.Model
+	OrderID         uint        `json:"order_id" gorm:"index;not null"`
+	VendorID        uint        `json:"vendor_id" gorm:"index;not null"`
+	Vendor          *Vendor     `json:"vendor,omitempty" gorm:"foreignKey:VendorID"`
+	Status          OrderStatus `json:"status" gorm:"type:varchar(20);default:'pending'"`
+	Subtotal        float64     `json:"subtotal" gorm:"not null"`
+	ShippingAddress string      `json:"shipping_address"` // copied from the order for the vendor to ship to
+	Items           []OrderItem `json:"items" gorm:"foreignKey:VendorOrderID"`
+
+	Carrier        string     `json:"carrier"`
+	TrackingNumber string     `json:"tracking_number"`
+	AcceptedAt     *time.Time `json:"accepted_at"`
+	PackedAt       *time.Time `json:"packed_at"`
+	ShippedAt      *time.Time `json:"shipped_at"`
 }
 
 type OrderItem struct {
@@ -31,13 +82,24 @@ type OrderItem struct {
 	Quantity  int     `json:"quantity" gorm:"not null"`
 	UnitPrice float64 `json:"unit_price" gorm:"not null"`
 	Product   Product `json:"product" gorm:"foreignKey:ProductID"`
+
+	VendorOrderID *uint                `json:"vendor_order_id" gorm:"index"`
+	Components    []OrderItemComponent `json:"components,omitempty" gorm:"foreignKey:OrderItemID"` // bundles only
 }
 
 type CreateOrderRequest struct {
-	ShippingAddress string `json:"shipping_address" binding:"required"`
+	ShippingAddress string `json:"shipping_address"` // not needed when every item is digital
 	PaymentMethod   string `json:"payment_method" binding:"required"`
+	RecoveryToken   string `json:"recovery_token"` // from the link of an abandoned cart email
 }
 
 type UpdateOrderStatusRequest struct {
-	Status OrderStatus `json:"status" binding:"required,oneof=pending processing shipped delivered cancelled"`
+	Status  OrderStatus `json:"status" binding:"required,oneof=pending paid payment_failed processing shipped delivered cancelled refund_pending refunded"`
+	Note    string      `json:"note"`
+	Restock *bool       `json:"restock"` // refunded only: false keeps goods that weren't returned out of stock (default true)
+}
+
+type ShipVendorOrderRequest struct {
+	Carrier        string `json:"carrier"`
+	TrackingNumber string `json:"tracking_number" binding:"required"`
 }
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	PriceSourceManual        = "manual"
+	PriceSourceScheduleStart = "schedule_start"
+	PriceSourceScheduleEnd   = "schedule_end"
+	PriceSourceRollback      = "rollback"
+
+	PriceScheduleScheduled = "scheduled"
+	PriceScheduleActive    = "active"
+	PriceScheduleCompleted = "completed"
+	PriceScheduleCancelled = "cancelled"
+)
+
+// ProductPriceHistory records every change of a product's price
+type ProductPriceHistory struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ProductID  uint      `gorm:"not null;index" json:"product_id"`
+	OldPrice   float64   `json:"old_price"`
+	NewPrice   float64   `json:"new_price"`
+	ActorID    *uint     `json:"actor_id"` // nullable for the scheduler
+	Source     string    `gorm:"size:30" json:"source"`
+	ScheduleID *uint     `json:"schedule_id"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// PriceSchedule is a future price for a product, e.g. a sale. While it is
+// active the product shows the original price as CompareAtPrice, and when
+// EndsAt passes the original price is restored.
+type PriceSchedule struct {
+	gorm.Model
+	ProductID     uint       `json:"product_id" gorm:"not null;index"`
+	Price         float64    `json:"price" gorm:"not null"`
+	StartsAt      time.Time  `json:"starts_at" gorm:"not null;index"`
+	EndsAt        *time.Time `json:"ends_at" gorm:"index"` // nil keeps the price permanently
+	Status        string     `json:"status" gorm:"size:20;default:'scheduled';index"`
+	OriginalPrice *float64   `json:"original_price"` // captured when the schedule starts
+	CreatedBy     uint       `json:"created_by"`
+}
+
+type CreatePriceScheduleRequest struct {
+	Price    float64    `json:"price" binding:"required,gt=0"`
+	StartsAt time.Time  `json:"starts_at" binding:"required"`
+	EndsAt   *time.Time `json:"ends_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// ProductView is the last time a viewer looked at a product. Viewer is
+// "user:<id>" for logged-in users and "client:<id>" for anonymous visitors.
+type ProductView struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Viewer    string    `gorm:"size:80;not null;uniqueIndex:idx_product_view_viewer" json:"-"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_product_view_viewer" json:"product_id"`
+	Product   *Product  `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	UserID    *uint     `gorm:"index" json:"user_id"`
+	ViewedAt  time.Time `gorm:"not null;index" json:"viewed_at"`
+}
+
+// ProductViewStat counts the views of a product per day
+type ProductViewStat struct {
+	ProductID uint      `gorm:"primaryKey" json:"product_id"`
+	Day       time.Time `gorm:"primaryKey;type:date" json:"day"`
+	Views     int       `gorm:"not null" json:"views"`
+}
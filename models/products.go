@@ -1,6 +1,22 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	AvailabilityInStock    = "in_stock"
+	AvailabilityLowStock   = "low_stock"
+	AvailabilityOutOfStock = "out_of_stock"
+)
+
+const (
+	ProductTypeSimple  = "simple"
+	ProductTypeBundle  = "bundle"
+	ProductTypeDigital = "digital" // downloadable, needs no shipping
+)
 
 type Product struct {
 	gorm.Model
@@ -15,7 +31,54 @@ type Product struct {
 	Price       float64  `json:"price"`
 	Stock       int      `json:"stock"`
 	ImageURL    string   `json:"image_url"`
-	CategoryID  uint     `json:"category_id" gorm:"not null"`
+	CategoryID  uint     `json:"category_id" gorm:"not null;index"`
 	Category    Category `json:"category" gorm:"foreignKey:CategoryID"`
 	Status      string   `gorm:"size:50;default:'draft'" json:"status"`
+
+	// bundles are sold as one product made of other products
+	Type       string            `gorm:"size:20;default:'simple'" json:"type"` // simple/bundle/digital
+	Components []BundleComponent `gorm:"foreignKey:BundleID" json:"components,omitempty"`
+
+	// digital products: downloads allowed per order item (0 = unlimited)
+	DownloadLimit int `json:"download_limit" gorm:"default:5"`
+
+	// SEO: Slug is unique across live products, old slugs live in ProductSlugHistory
+	Slug            string `json:"slug" gorm:"size:255;uniqueIndex:idx_product_slug,where:slug <> '' AND deleted_at IS NULL"`
+	MetaTitle       string `json:"meta_title" gorm:"size:255"`
+	MetaDescription string `json:"meta_description" gorm:"size:500"`
+
+	Tags []Tag `json:"tags" gorm:"many2many:product_tags"`
+
+	ViewCount int `json:"view_count" gorm:"default:0;index"` // counted views, for "popular" sorting
+
+	CompareAtPrice *float64 `json:"compare_at_price"` // original price shown during a scheduled sale
+
+	// scheduled publishing: draft -> published at PublishAt, published -> archived at UnpublishAt
+	PublishAt   *time.Time `json:"publish_at" gorm:"index"`
+	UnpublishAt *time.Time `json:"unpublish_at" gorm:"index"`
+
+	// moderation: feedback of the last superadmin review
+	ModerationNote string     `json:"moderation_note" gorm:"type:text"`
+	ReviewedBy     *uint      `json:"reviewed_by"`
+	ReviewedAt     *time.Time `json:"reviewed_at"`
+
+	// low-stock alerting: the vendor is alerted once when Stock drops to the threshold
+	ReorderThreshold  int        `json:"reorder_threshold" gorm:"default:5"`
+	LowStockAlertedAt *time.Time `json:"low_stock_alerted_at"`
+	Availability      string     `json:"availability" gorm:"-"` // in_stock, low_stock, out_of_stock
+}
+
+// AfterFind fills the computed availability of a loaded product
+func (p *Product) AfterFind(tx *gorm.DB) error {
+	switch {
+	case p.Type == ProductTypeDigital:
+		p.Availability = AvailabilityInStock
+	case p.Stock <= 0:
+		p.Availability = AvailabilityOutOfStock
+	case p.Stock <= p.ReorderThreshold:
+		p.Availability = AvailabilityLowStock
+	default:
+		p.Availability = AvailabilityInStock
+	}
+	return nil
 }
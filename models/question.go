@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	QAStatusVisible = "visible"
+	QAStatusHidden  = "hidden"
+
+	ReportStatusOpen      = "open"
+	ReportStatusResolved  = "resolved"
+	ReportStatusDismissed = "dismissed"
+)
+
+type ProductQuestion struct {
+	gorm.Model
+	ProductID uint            `json:"product_id" gorm:"not null;index"`
+	Product   *Product        `json:"product,omitempty" gorm:"foreignKey:ProductID"`
+	UserID    uint            `json:"user_id" gorm:"not null"`
+	User      User            `json:"user" gorm:"foreignKey:UserID"`
+	Body      string          `json:"body" gorm:"type:text;not null"`
+	Status    string          `json:"status" gorm:"size:20;default:'visible'"` // visible/hidden
+	Answers   []ProductAnswer `json:"answers" gorm:"foreignKey:QuestionID"`
+}
+
+type ProductAnswer struct {
+	gorm.Model
+	QuestionID      uint   `json:"question_id" gorm:"not null;index"`
+	UserID          uint   `json:"user_id" gorm:"not null"`
+	User            User   `json:"user" gorm:"foreignKey:UserID"`
+	Body            string `json:"body" gorm:"type:text;not null"`
+	IsVendor        bool   `json:"is_vendor"`         // answered by the product's vendor
+	IsVerifiedBuyer bool   `json:"is_verified_buyer"` // answered by a customer who received the product
+	Upvotes         int    `json:"upvotes" gorm:"default:0"`
+	Status          string `json:"status" gorm:"size:20;default:'visible'"` // visible/hidden
+}
+
+// AnswerVote keeps one upvote per user per answer
+type AnswerVote struct {
+	AnswerID  uint      `gorm:"primaryKey" json:"answer_id"`
+	UserID    uint      `gorm:"primaryKey" json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QAReport is a user report against a question or an answer
+type QAReport struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	ReporterID uint       `gorm:"not null" json:"reporter_id"`
+	QuestionID *uint      `json:"question_id"`
+	AnswerID   *uint      `json:"answer_id"`
+	Reason     string     `gorm:"type:text" json:"reason"`
+	Status     string     `gorm:"size:20;default:'open'" json:"status"` // open/resolved/dismissed
+	ResolvedBy *uint      `json:"resolved_by"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+	Note       string     `gorm:"type:text" json:"note"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+type AskQuestionRequest struct {
+	Body string `json:"body" binding:"required,min=5,max=1000"`
+}
+
+type AnswerQuestionRequest struct {
+	Body string `json:"body" binding:"required,min=2,max=2000"`
+}
+
+type ReportRequest struct {
+	Reason string `json:"reason" binding:"required,max=500"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+const (
+	RecommendationBoughtTogether = "bought_together"
+	RecommendationSimilar        = "similar"
+)
+
+// ProductRecommendation is a precomputed recommendation, rebuilt by the
+// recommendation job. Rank 1 is the strongest recommendation of its kind.
+type ProductRecommendation struct {
+	ProductID     uint      `gorm:"primaryKey" json:"product_id"`
+	RecommendedID uint      `gorm:"primaryKey" json:"recommended_id"`
+	Kind          string    `gorm:"primaryKey;size:20" json:"kind"` // bought_together/similar
+	Score         float64   `json:"score"`
+	Rank          int       `gorm:"not null" json:"rank"`
+	ComputedAt    time.Time `json:"computed_at"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+const (
+	ReservationActive    = "active"    // stock is held while payment is pending
+	ReservationCommitted = "committed" // the order went ahead, stock is sold
+	ReservationReleased  = "released"  // stock was returned (cancelled or expired)
+)
+
+// StockReservation holds stock taken by a pending order until it is paid,
+// cancelled or the reservation expires
+type StockReservation struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
+	OrderID   uint      `gorm:"not null;index" json:"order_id"`
+	Quantity  int       `gorm:"not null" json:"quantity"`
+	Status    string    `gorm:"size:20;default:'active';index" json:"status"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	RevisionActionUpdate   = "update"
+	RevisionActionRollback = "rollback"
+)
+
+// ProductRevision is a snapshot of a product's editable fields taken right
+// before an update (or rollback) changed them
+type ProductRevision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_product_revision_version" json:"product_id"`
+	Version   int       `gorm:"not null;uniqueIndex:idx_product_revision_version" json:"version"`
+	Snapshot  string    `gorm:"type:text;not null" json:"-"` // JSON of helper.ProductSnapshot
+	Action    string    `gorm:"size:20" json:"action"`       // update/rollback
+	ActorID   *uint     `json:"actor_id"`
+	Actor     *User     `gorm:"foreignKey:ActorID" json:"actor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
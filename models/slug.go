@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// ProductSlugHistory keeps the previous slugs of a product so old URLs can
+// be redirected to the current one
+type ProductSlugHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ProductID uint      `gorm:"not null;index" json:"product_id"`
+	Slug      string    `gorm:"size:255;not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
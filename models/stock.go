@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+type StockMovementType string
+
+const (
+	StockMovementSale         StockMovementType = "sale"
+	StockMovementCancellation StockMovementType = "cancellation"
+	StockMovementReturn       StockMovementType = "return"
+	StockMovementAdjustment   StockMovementType = "adjustment"
+	StockMovementImport       StockMovementType = "import"
+)
+
+// StockMovement is an append-only ledger entry for a product's stock.
+// Quantity is signed: negative for stock leaving, positive for stock coming in.
+type StockMovement struct {
+	ID         uint              `gorm:"primaryKey" json:"id"`
+	ProductID  uint              `gorm:"not null;index" json:"product_id"`
+	Type       StockMovementType `gorm:"type:varchar(20);not null" json:"type"`
+	Quantity   int               `gorm:"not null" json:"quantity"`
+	StockAfter int               `gorm:"not null" json:"stock_after"`
+	ActorID    *uint             `json:"actor_id"` // nullable for system
+	Reason     string            `gorm:"type:text" json:"reason"`
+	Reference  string            `gorm:"size:100;index" json:"reference"` // e.g. "order:12"
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// StockReferenceLedgerReset marks the movement that resets Product.Stock to
+// the ledger balance. It records the correction but isn't part of the
+// balance itself, or the reset would move the ledger away from the stock.
+const StockReferenceLedgerReset = "reconciliation:ledger"
+
+type AdjustStockRequest struct {
+	Quantity int               `json:"quantity" binding:"required"` // signed delta
+	Type     StockMovementType `json:"type" binding:"required,oneof=adjustment return import"`
+	Reason   string            `json:"reason" binding:"required"`
+}
+
+type ReconcileStockRequest struct {
+	// "stock" keeps Product.Stock and books the difference into the ledger,
+	// "ledger" resets Product.Stock to the ledger balance
+	Strategy string `json:"strategy" binding:"required,oneof=stock ledger"`
+	Reason   string `json:"reason"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	SubscriptionSourceDirect   = "direct"
+	SubscriptionSourceWishlist = "wishlist"
+)
+
+// StockSubscription asks to be emailed once an out of stock product is back.
+// TriggeredAt is set when the stock goes from zero to positive; the
+// notifier job then emails the user and removes the subscription.
+type StockSubscription struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      uint       `gorm:"not null;uniqueIndex:idx_stock_subscription_user_product" json:"user_id"`
+	ProductID   uint       `gorm:"not null;uniqueIndex:idx_stock_subscription_user_product;index" json:"product_id"`
+	Product     *Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+	Source      string     `gorm:"size:20;default:'direct'" json:"source"` // direct/wishlist
+	TriggeredAt *time.Time `gorm:"index" json:"triggered_at"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
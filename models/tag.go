@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Tag is a free-form product label, shared between products by slug
+type Tag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Name      string    `gorm:"size:100;not null" json:"name"`
+	Slug      string    `gorm:"size:120;not null;uniqueIndex" json:"slug"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -8,5 +8,20 @@ type WishlistItem struct {
 	ProductID uint      `gorm:"not null" json:"product_id"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	NotifyWhenInStock bool     `gorm:"default:false" json:"notify_when_in_stock"` // back-in-stock email requested
+	PriceAtAdd        float64  `json:"price_at_add"`                              // product price when the item was saved
+	LastNotifiedPrice *float64 `json:"last_notified_price"`                       // price reported in the last price-drop alert
 	// Add any additional fields needed
 }
+
+// PriceAlertPreference is a user's opt-in for wishlist price-drop alerts
+type PriceAlertPreference struct {
+	UserID         uint      `gorm:"primaryKey" json:"user_id"`
+	Enabled        bool      `json:"enabled"`
+	MinDropPercent float64   `json:"min_drop_percent"` // alert only for drops of at least this percent
+	MinDropAmount  float64   `json:"min_drop_amount"`  // and at least this amount
+	EmailEnabled   bool      `json:"email_enabled"`    // in-app alerts are always sent
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
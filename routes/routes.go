@@ -18,8 +18,8 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 	corsConfig := cors.Config{
 		AllowOrigins:     []string{"https://e-com-nextjs-six.vercel.app"},
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", "X-Client-ID", "X-Cart-Token"},
+		ExposeHeaders:    []string{"Content-Length", "X-Cart-Token"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}
@@ -34,6 +34,18 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 	categoryController := controllers.NewCategoryController(db)
 	wishlistController := controllers.NewWishlistController(db)
 	vendorController := controllers.NewVendorController(db, &authController)
+	vendorOrderController := controllers.NewVendorOrderController(db)
+	questionController := controllers.NewQuestionController(db)
+	notificationController := controllers.NewNotificationController(db)
+	stockController := controllers.NewStockController(db)
+	stockSubscriptionController := controllers.NewStockSubscriptionController(db)
+	priceController := controllers.NewPriceController(db)
+	moderationController := controllers.NewModerationController(db)
+	revisionController := controllers.NewRevisionController(db)
+	collectionController := controllers.NewCollectionController(db)
+	recommendationController := controllers.NewRecommendationController(db)
+	productViewController := controllers.NewProductViewController(db)
+	digitalController := controllers.NewDigitalController(db)
 
 	//  PUBLIC ROUTES
 	r.POST("/register", authController.Register)
@@ -47,6 +59,27 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 	authGroup.Use(middlewares.AuthMiddleware(db))
 	{
 		authGroup.GET("/me", authController.GetProfile)
+
+		// notifications
+		authGroup.GET("/notifications", notificationController.GetNotifications)
+		authGroup.PUT("/notifications/read-all", notificationController.MarkAllNotificationsRead)
+		authGroup.PUT("/notifications/:id/read", notificationController.MarkNotificationRead)
+
+		// product questions & answers
+		authGroup.POST("/products/:id/questions", questionController.AskQuestion)
+		authGroup.POST("/questions/:questionId/answers", questionController.AnswerQuestion)
+		authGroup.POST("/questions/:questionId/report", questionController.ReportQuestion)
+		authGroup.POST("/answers/:answerId/upvote", questionController.UpvoteAnswer)
+		authGroup.POST("/answers/:answerId/report", questionController.ReportAnswer)
+
+		// back-in-stock notifications
+		authGroup.GET("/notify-me", stockSubscriptionController.GetSubscriptions)
+		authGroup.POST("/products/:id/notify-me", stockSubscriptionController.Subscribe)
+		authGroup.DELETE("/products/:id/notify-me", stockSubscriptionController.Unsubscribe)
+
+		// browsing history
+		authGroup.GET("/recently-viewed", productViewController.GetRecentlyViewed)
+		authGroup.DELETE("/recently-viewed", productViewController.ClearRecentlyViewed)
 	}
 
 	//  PRODUCT ROUTES
@@ -56,7 +89,9 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		customerPruduct := productGroup.Group("/customer")
 		{
 			customerPruduct.GET("", productController.GetProductsCustomer)
-			customerPruduct.GET("/:id", productController.GetProductByIDCustomer)
+			customerPruduct.GET("/:id", middlewares.OptionalAuthMiddleware(db), productController.GetProductByIDCustomer)
+			customerPruduct.GET("/:id/questions", questionController.GetProductQuestions)
+			customerPruduct.GET("/:id/recommendations", recommendationController.GetRecommendations)
 		}
 
 		// protected (admin or seller or vendor)
@@ -72,6 +107,36 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 
 			// status update
 			vendorProduct.PUT("/:id/status", productController.UpdateStatus)
+			vendorProduct.PUT("/:id/schedule", productController.SchedulePublishing)
+
+			// low stock dashboard
+			vendorProduct.GET("/low-stock", productController.GetLowStockProducts)
+
+			// view analytics
+			vendorProduct.GET("/analytics/views", productViewController.GetViewAnalytics)
+
+			// stock ledger
+			vendorProduct.GET("/stock/reconciliation", stockController.GetReconciliationReport)
+			vendorProduct.POST("/:id/stock", stockController.AdjustStock)
+			vendorProduct.GET("/:id/stock/history", stockController.GetStockHistory)
+			vendorProduct.POST("/:id/stock/reconcile", stockController.ReconcileStock)
+
+			// pricing
+			vendorProduct.GET("/:id/price-history", priceController.GetPriceHistoryVendor)
+			vendorProduct.GET("/:id/price-schedules", priceController.GetPriceSchedules)
+			vendorProduct.POST("/:id/price-schedules", priceController.CreatePriceSchedule)
+			vendorProduct.DELETE("/:id/price-schedules/:scheduleId", priceController.CancelPriceSchedule)
+
+			// digital products
+			vendorProduct.GET("/:id/files", digitalController.GetFiles)
+			vendorProduct.POST("/:id/files", digitalController.UploadFile)
+			vendorProduct.DELETE("/:id/files/:fileId", digitalController.DeleteFile)
+			vendorProduct.GET("/:id/license-keys", digitalController.GetLicenseKeys)
+			vendorProduct.POST("/:id/license-keys", digitalController.AddLicenseKeys)
+
+			// revisions
+			vendorProduct.GET("/:id/revisions", revisionController.GetRevisions)
+			vendorProduct.POST("/:id/revisions/:revisionId/rollback", revisionController.RollbackRevision)
 		}
 
 		// superadmin can view all product (all without draft)
@@ -79,6 +144,16 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		{
 			superadminProduct.GET("", productController.GetProductsSuperadmin)
 			superadminProduct.GET("/:id", productController.GetProductByIDSuperadmin)
+
+			// moderation (superadmin only)
+			moderation := superadminProduct.Group("")
+			moderation.Use(middlewares.AuthMiddleware(db), middlewares.SuperAdminMiddleware(db))
+			{
+				moderation.GET("/review-queue", moderationController.GetReviewQueue)
+				moderation.GET("/:id/moderation", moderationController.GetModerationHistory)
+				moderation.PUT("/:id/approve", moderationController.ApproveProduct)
+				moderation.PUT("/:id/reject", moderationController.RejectProduct)
+			}
 		}
 	}
 
@@ -89,6 +164,7 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		order.GET("/", orderController.GetOrders)
 		order.GET("/:orderId", orderController.GetOrder)
 		order.PUT("/:orderId/cancel", orderController.CancelOrder)
+		order.GET("/:orderId/downloads", digitalController.GetOrderDownloads)
 
 		// admin only
 		adminOrder := order.Group("/")
@@ -106,7 +182,11 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		wishlistGroup.DELETE("/:id", wishlistController.RemoveFromWishlist)
 		wishlistGroup.DELETE("/clear", wishlistController.ClearWishlist)
 		wishlistGroup.PUT("/update/:id", wishlistController.UpdateWishlistItem)
+		wishlistGroup.PUT("/:id/notify", wishlistController.SetWishlistNotify)
+		wishlistGroup.GET("/price-alerts", wishlistController.GetPriceAlertPreference)
+		wishlistGroup.PUT("/price-alerts", wishlistController.UpdatePriceAlertPreference)
 		wishlistGroup.POST("/import", wishlistController.ImportWishlist)
+		wishlistGroup.POST("/:id/move-to-cart", cartController.MoveWishlistToCart)
 	}
 
 	//  CART ROUTES
@@ -117,13 +197,37 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		cartGroup.PUT("/items/:itemId", cartController.UpdateCartItem)
 		cartGroup.DELETE("/items/:itemId", cartController.RemoveFromCart)
 		cartGroup.DELETE("/clear", cartController.ClearCart)
+		cartGroup.POST("/items/:itemId/save-for-later", cartController.SaveForLater)
+		cartGroup.POST("/items/:itemId/move-to-cart", cartController.MoveToCart)
+		cartGroup.POST("/items/:itemId/move-to-wishlist", cartController.MoveToWishlist)
 	}
 
+	//  GUEST CART ROUTES (X-Cart-Token header, merged into the user's cart at login)
+	guestCartGroup := r.Group("/guest/cart")
+	{
+		guestCartGroup.GET("/", cartController.GetCart)
+		guestCartGroup.POST("/items", cartController.AddToCart)
+		guestCartGroup.PUT("/items/:itemId", cartController.UpdateCartItem)
+		guestCartGroup.DELETE("/items/:itemId", cartController.RemoveFromCart)
+		guestCartGroup.DELETE("/clear", cartController.ClearCart)
+		guestCartGroup.POST("/items/:itemId/save-for-later", cartController.SaveForLater)
+		guestCartGroup.POST("/items/:itemId/move-to-cart", cartController.MoveToCart)
+	}
+
+	//  DIGITAL DOWNLOADS (signed links)
+	r.GET("/downloads/:grantId/files/:fileId", digitalController.Download)
+
+	//  TAGS & COLLECTIONS
+	r.GET("/api/v1/tags", collectionController.GetTags)
+	r.GET("/api/v1/collections", collectionController.GetCollections)
+	r.GET("/api/v1/collections/:slug", collectionController.GetCollection)
+
 	//  PRODUCT CATEGORY ROUTES
 	categoryRoutes := r.Group("/categories")
 	{
 		categoryRoutes.GET("/", categoryController.GetCategories)
-		categoryRoutes.GET("/:id", categoryController.GetCategories)
+		categoryRoutes.GET("/tree", categoryController.GetCategoryTree)
+		categoryRoutes.GET("/:id", categoryController.GetCategory)
 	}
 
 	//  SUPERADMIN  MANAGEMENT
@@ -140,8 +244,24 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		superAdminGroup.GET("/categories", categoryController.ListCategories)
 		superAdminGroup.POST("/categories", categoryController.CreateCategory)
 		superAdminGroup.PUT("/categories/:id", categoryController.UpdateCategory)
+		superAdminGroup.PUT("/categories/:id/move", categoryController.MoveCategory)
+		superAdminGroup.PUT("/categories/reorder", categoryController.ReorderCategories)
 		superAdminGroup.DELETE("/categories/:id", categoryController.DeleteCategory)
 
+		superAdminGroup.GET("/products/:id/price-history", priceController.GetPriceHistorySuperadmin)
+
+		// collections
+		superAdminGroup.GET("/collections", collectionController.ListCollections)
+		superAdminGroup.POST("/collections", collectionController.CreateCollection)
+		superAdminGroup.PUT("/collections/:id", collectionController.UpdateCollection)
+		superAdminGroup.DELETE("/collections/:id", collectionController.DeleteCollection)
+		superAdminGroup.PUT("/collections/:id/products", collectionController.SetCollectionProducts)
+
+		// product Q&A moderation
+		superAdminGroup.GET("/qa/reports", questionController.ListReports)
+		superAdminGroup.PUT("/qa/reports/:id/resolve", questionController.ResolveReport)
+		superAdminGroup.PUT("/qa/questions/:id/status", questionController.SetQuestionStatus)
+		superAdminGroup.PUT("/qa/answers/:id/status", questionController.SetAnswerStatus)
 	}
 
 	//  VENDOR ROUTES
@@ -153,6 +273,17 @@ func SetupRoutes(db *gorm.DB) *gin.Engine {
 		// apply for delete vendor account
 	}
 
+	//  VENDOR ORDERS (the vendor's part of each order)
+	vendorOrders := r.Group("/vendor/orders")
+	vendorOrders.Use(middlewares.AuthMiddleware(db), middlewares.AdminSellerMiddleware())
+	{
+		vendorOrders.GET("", vendorOrderController.GetVendorOrders)
+		vendorOrders.GET("/:id", vendorOrderController.GetVendorOrder)
+		vendorOrders.PUT("/:id/accept", vendorOrderController.AcceptVendorOrder)
+		vendorOrders.PUT("/:id/pack", vendorOrderController.PackVendorOrder)
+		vendorOrders.PUT("/:id/ship", vendorOrderController.ShipVendorOrder)
+	}
+
 	//  SUPERADMIN VENDOR MANAGEMENT
 	superAdminVendor := r.Group("/super-admin/vendors")
 	superAdminVendor.Use(middlewares.AuthMiddleware(db), middlewares.SuperAdminMiddleware(db))
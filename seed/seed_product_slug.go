@@ -0,0 +1,34 @@
+package seed
+
+import (
+	"log"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// SeedProductSlugs gives products created before slugs existed a slug
+func SeedProductSlugs(db *gorm.DB) {
+	var products []models.Product
+	if err := db.Where("slug = '' OR slug IS NULL").Find(&products).Error; err != nil {
+		log.Printf("Failed to load products without slug: %v", err)
+		return
+	}
+
+	for i := range products {
+		product := &products[i]
+		err := db.Transaction(func(tx *gorm.DB) error {
+			if err := helper.AssignProductSlug(tx, product, ""); err != nil {
+				return err
+			}
+			return tx.Model(product).Update("slug", product.Slug).Error
+		})
+		if err != nil {
+			log.Printf("Failed to seed slug for product %d: %v", product.ID, err)
+		}
+	}
+	if len(products) > 0 {
+		log.Printf("Seeded slugs for %d products", len(products))
+	}
+}
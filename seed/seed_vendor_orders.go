@@ -0,0 +1,49 @@
+package seed
+
+import (
+	"log"
+
+	"github.com/abdullahalsazib/e-com-backend/helper"
+	"github.com/abdullahalsazib/e-com-backend/models"
+	"gorm.io/gorm"
+)
+
+// SeedVendorOrders splits orders placed before vendor orders existed
+func SeedVendorOrders(db *gorm.DB) {
+	var orders []models.Order
+	if err := db.Preload("Items").
+		Where("NOT EXISTS (SELECT 1 FROM vendor_orders WHERE vendor_orders.order_id = orders.id)").
+		Where("EXISTS (SELECT 1 FROM order_items WHERE order_items.order_id = orders.id)").
+		Find(&orders).Error; err != nil {
+		log.Printf("Failed to load orders without vendor orders: %v", err)
+		return
+	}
+
+	for i := range orders {
+		order := &orders[i]
+		productIDs := make([]uint, 0, len(order.Items))
+		for _, item := range order.Items {
+			productIDs = append(productIDs, item.ProductID)
+		}
+
+		// products may have been deleted since
+		var products []models.Product
+		if err := db.Unscoped().Select("id", "vendor_id").Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+			log.Printf("Failed to load products of order %d: %v", order.ID, err)
+			continue
+		}
+		vendorOf := make(map[uint]uint, len(products))
+		for _, product := range products {
+			vendorOf[product.ID] = product.VendorID
+		}
+
+		if err := db.Transaction(func(tx *gorm.DB) error {
+			return helper.SplitOrderByVendor(tx, order, vendorOf)
+		}); err != nil {
+			log.Printf("Failed to split order %d by vendor: %v", order.ID, err)
+		}
+	}
+	if len(orders) > 0 {
+		log.Printf("Split %d orders by vendor", len(orders))
+	}
+}
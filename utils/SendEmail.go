@@ -0,0 +1,36 @@
+package utils
+
+import (
+	"errors"
+	"net/smtp"
+	"os"
+	"strings"
+)
+
+// SendEmail sends an HTML email using the SMTP settings from the environment
+func SendEmail(toEmail, subject, htmlBody string) error {
+	from := os.Getenv("SMTP_USER")
+	password := os.Getenv("SMTP_PASSWORD")
+	if from == "" || password == "" {
+		return errors.New("SMTP_USER or SMTP_PASSWORD is not set")
+	}
+
+	smtpHost := os.Getenv("SMTP_HOST")
+	if smtpHost == "" {
+		smtpHost = "smtp.gmail.com"
+	}
+	smtpPort := os.Getenv("SMTP_PORT")
+	if smtpPort == "" {
+		smtpPort = "587"
+	}
+
+	// subjects carry names users typed: a line break would start a new header
+	subject = strings.NewReplacer("\r", " ", "\n", " ").Replace(subject)
+	message := []byte("Subject: " + subject + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/html; charset=\"UTF-8\"\r\n\r\n" +
+		htmlBody)
+
+	auth := smtp.PlainAuth("", from, password, smtpHost)
+	return smtp.SendMail(smtpHost+":"+smtpPort, auth, from, []string{toEmail}, message)
+}
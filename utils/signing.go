@@ -0,0 +1,35 @@
+package utils
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"os"
+)
+
+// RequireSigningKey stops the server when SIGNING_KEY is not set: guest cart
+// tokens, download links and cart recovery links signed with a known default
+// key could be forged
+func RequireSigningKey() {
+	if os.Getenv("SIGNING_KEY") == "" {
+		log.Fatal("SIGNING_KEY is not set")
+	}
+}
+
+// signingKey signs download links and similar tokens (env SIGNING_KEY)
+func signingKey() []byte {
+	return []byte(os.Getenv("SIGNING_KEY"))
+}
+
+// Sign returns the hex HMAC-SHA256 signature of the payload
+func Sign(payload string) string {
+	mac := hmac.New(sha256.New, signingKey())
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature reports whether signature is the payload's signature
+func VerifySignature(payload, signature string) bool {
+	return hmac.Equal([]byte(Sign(payload)), []byte(signature))
+}